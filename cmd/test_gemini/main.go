@@ -15,7 +15,7 @@ func main() {
 		log.Fatalf("Failed to initialize Gemini: %v", err)
 	}
 
-	quizService := service.NewQuizService(nil, nil, nil, nil) // Mock repos for pure generation test
+	quizService := service.NewQuizService(nil, nil, nil, nil, nil, nil, nil, nil, nil) // Mock repos for pure generation test
 
 	quiz, err := quizService.GenerateQuiz(
 		context.Background(),
@@ -25,6 +25,7 @@ func main() {
 		"A beginner level quiz on Go programming language features.",
 		3,
 		30,
+		[]string{"single_choice", "true_false"},
 	)
 
 	if err != nil {
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/joho/godotenv"
@@ -29,6 +30,7 @@ type Env struct {
 	FRONTEND_URL                    string
 	GEMINI_API_KEY                  string
 	GEMINI_MODEL                    string
+	ADMIN_EMAILS                    []string
 	// GEMINI_BASE_URL                 string
 }
 
@@ -99,6 +101,13 @@ func LoadEnv() *Env {
 		if geminiModel == "" {
 			log.Println("Model name and info is requires")
 		}
+		// Comma separated list of users allowed to manage every quiz
+		var adminEmails []string
+		for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+			if email = strings.TrimSpace(strings.ToLower(email)); email != "" {
+				adminEmails = append(adminEmails, email)
+			}
+		}
 		// geminiBaseUrl := os.Getenv("GEMINI_BASE_URL")
 		// if geminiBaseUrl == "" {
 		// 	log.Println("Gemini Base url is requires")
@@ -120,6 +129,7 @@ func LoadEnv() *Env {
 			FRONTEND_URL:                    frontend_url,
 			GEMINI_API_KEY:                  geminiKey,
 			GEMINI_MODEL:                    geminiModel,
+			ADMIN_EMAILS:                    adminEmails,
 			// GEMINI_BASE_URL:                 geminiBaseUrl,
 		}
 	})
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"google.golang.org/genai"
 )
@@ -39,7 +40,30 @@ func GenerateContent(ctx context.Context, prompt string) (string, error) {
 
 	return result.Text(), nil
 }
-func BuildPrompt(title, category, difficulty, description string, numQuestions, points int) string {
+
+// questionTypeSchemas shows the model the JSON expected for each question type.
+var questionTypeSchemas = map[string]string{
+	"single_choice": `{"type": "single_choice", "text": "question", "options": ["A", "B", "C", "D"], "answer": 0, "explanation": "why the answer is correct"}`,
+	"multi_select":  `{"type": "multi_select", "text": "question", "options": ["A", "B", "C", "D"], "answers": [0, 2], "explanation": "why the answer is correct"}`,
+	"true_false":    `{"type": "true_false", "text": "statement", "options": ["True", "False"], "answer": 0, "explanation": "why the answer is correct"}`,
+	"numeric":       `{"type": "numeric", "text": "question", "numeric_answer": 3.14, "tolerance": 0.01, "explanation": "why the answer is correct"}`,
+	"ordering":      `{"type": "ordering", "text": "question", "options": ["B", "A", "C"], "order": [1, 0, 2], "explanation": "why the answer is correct"}`,
+	"short_text":    `{"type": "short_text", "text": "question", "accepted_answers": ["answer", "alternative"], "fuzzy": true, "explanation": "why the answer is correct"}`,
+}
+
+// BuildPrompt builds the quiz generation prompt. questionTypes restricts the
+// question types the model may use; it defaults to single_choice.
+func BuildPrompt(title, category, difficulty, description string, numQuestions, points int, questionTypes []string) string {
+	examples := make([]string, 0, len(questionTypes))
+	for _, t := range questionTypes {
+		if schema, ok := questionTypeSchemas[t]; ok {
+			examples = append(examples, "    "+schema)
+		}
+	}
+	if len(examples) == 0 {
+		examples = append(examples, "    "+questionTypeSchemas["single_choice"])
+	}
+
 	return fmt.Sprintf(`
 	Generate a quiz with the following details:
 
@@ -59,18 +83,16 @@ Return ONLY valid JSON:
   "difficulty": "%s",
   "points": %d,
   "questions": [
-    {
-      "text": "question",
-      "options": ["A", "B", "C", "D"],
-      "answer": 0
-    }
+%s
   ]
 }
 
 Rules:
-- answer must be index (0-3)
-- no explanation
-- no extra text
-`, title, category, difficulty, description, numQuestions, points, title, category, description, difficulty, points)
+- every question must use one of the question shapes shown above, mixing them if more than one is shown
+- answer, answers and order are 0-based indexes into options
+- order lists the option indexes in the correct sequence
+- explanation is one or two sentences a learner reads after answering, saying why the answer is correct
+- add "reference": "<url or source>" to a question only when there is a well known source for it
+- no extra text outside the JSON
+`, title, category, difficulty, description, numQuestions, points, title, category, description, difficulty, points, strings.Join(examples, ",\n"))
 }
-
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/service"
+	"github.com/sachinggsingh/quiz/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AttemptHandler struct {
+	attemptService *service.AttemptService
+}
+
+func NewAttemptHandler(attemptService *service.AttemptService) *AttemptHandler {
+	return &AttemptHandler{
+		attemptService: attemptService,
+	}
+}
+
+func (h *AttemptHandler) StartAttempt(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	quizID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+
+	// User ID and role are already set in context by Authenticate middleware
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// ?mode=practice starts an attempt submitted to /practice, which never counts
+	var practice bool
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "ranked":
+	case "practice":
+		practice = true
+	default:
+		http.Error(w, "mode must be ranked or practice", http.StatusBadRequest)
+		return
+	}
+
+	attempt, err := h.attemptService.StartAttempt(r.Context(), actor, quizID, r.URL.Query().Get("share"), practice)
+	if err != nil {
+		http.Error(w, err.Error(), attemptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attempt)
+}
+
+// ListAttempts returns the authenticated user's attempt history, paginated with ?page=&limit=
+// and optionally filtered with ?status=in_progress|submitted|expired
+func (h *AttemptHandler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	status := model.AttemptStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", model.AttemptInProgress, model.AttemptSubmitted, model.AttemptExpired:
+	default:
+		http.Error(w, "status must be in_progress, submitted or expired", http.StatusBadRequest)
+		return
+	}
+
+	page, limit := pagination(r, 20, 100)
+	attempts, total, err := h.attemptService.ListAttempts(r.Context(), userID, status, page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"attempts": attempts,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetAttempt returns one attempt of the authenticated user with its per-question results
+func (h *AttemptHandler) GetAttempt(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	attemptID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid attempt id", http.StatusBadRequest)
+		return
+	}
+
+	attempt, err := h.attemptService.GetAttempt(r.Context(), userID, attemptID)
+	if err != nil {
+		http.Error(w, err.Error(), attemptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(attempt)
+}
+
+// ReviewAttempt returns every question of a submitted attempt with the user's answer,
+// the correct answer and its explanation
+func (h *AttemptHandler) ReviewAttempt(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	attemptID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid attempt id", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.attemptService.ReviewAttempt(r.Context(), userID, attemptID)
+	if err != nil {
+		http.Error(w, err.Error(), attemptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(review)
+}
+
+// SaveAnswers stores answers of an open attempt so it can be resumed: {"answers": {"0": 1, "2": "text"}}.
+// A null answer clears one.
+func (h *AttemptHandler) SaveAnswers(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	attemptID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid attempt id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Answers map[string]any `json:"answers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attempt, err := h.attemptService.SaveAnswers(r.Context(), userID, attemptID, req.Answers)
+	if err != nil {
+		http.Error(w, err.Error(), attemptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(attempt)
+}
+
+// ResumeAttempt serves an open attempt again with its questions, saved answers and time left
+func (h *AttemptHandler) ResumeAttempt(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	attemptID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid attempt id", http.StatusBadRequest)
+		return
+	}
+
+	attempt, err := h.attemptService.ResumeAttempt(r.Context(), userID, attemptID)
+	if err != nil {
+		http.Error(w, err.Error(), attemptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(attempt)
+}
+
+// RevealHint reveals the next hint of a question of an open attempt: {"question": 2},
+// the position the question is shown at. Each hint costs points when the attempt is graded.
+func (h *AttemptHandler) RevealHint(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	attemptID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid attempt id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Question *int `json:"question"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Question == nil {
+		http.Error(w, "question is required", http.StatusBadRequest)
+		return
+	}
+
+	reveal, err := h.attemptService.RevealHint(r.Context(), userID, attemptID, *req.Question)
+	if err != nil {
+		http.Error(w, err.Error(), attemptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reveal)
+}
+
+// pagination reads ?page= and ?limit= with sane bounds.
+func pagination(r *http.Request, defaultLimit int64, maxLimit int64) (int64, int64) {
+	page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	if err != nil || limit < 1 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return page, limit
+}
+
+// attemptErrorStatus maps attempt and submission errors to HTTP status codes.
+func attemptErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAttemptNotFound), errors.Is(err, service.ErrQuizNotFound), errors.Is(err, service.ErrQuestionNotFound), errors.Is(err, mongo.ErrNoDocuments):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrAttemptClosed), errors.Is(err, service.ErrQuizAlreadyAttempted), errors.Is(err, service.ErrRetakeLimitReached), errors.Is(err, service.ErrNotEnoughQuestions), errors.Is(err, service.ErrAttemptNotSubmitted), errors.Is(err, service.ErrStatsConflict), errors.Is(err, service.ErrAttemptMode), errors.Is(err, service.ErrNoHintsLeft):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidAnswers):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUpgradeRequired):
+		return http.StatusPaymentRequired
+	case errors.Is(err, service.ErrShareLinkInvalid):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrRetakeCooldown):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrAttemptExpired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
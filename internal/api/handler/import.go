@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sachinggsingh/quiz/internal/quizio"
+	"github.com/sachinggsingh/quiz/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxImportSize = 5 << 20
+
+// ImportQuiz creates a quiz from an uploaded JSON, CSV, GIFT or Moodle XML file.
+// The file is sent as the raw body or as the "file" field of a multipart form.
+// The format comes from ?format=, the file name or the Content-Type, and
+// ?title=&category=&difficulty=&description=&points= override what the file
+// carries. With ?dry_run=true only the validation report is returned.
+func (h *QuizHandler) ImportQuiz(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	query := r.URL.Query()
+
+	var body io.Reader = r.Body
+	format, ok := quizio.FormatFromContentType(r.Header.Get("Content-Type"))
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "missing file: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+		format, ok = quizio.FormatFromFilename(header.Filename)
+	}
+	if query.Get("format") != "" {
+		format, err = quizio.ParseFormat(query.Get("format"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ok = true
+	}
+	if !ok {
+		http.Error(w, "format is required: one of json, csv, gift, xml", http.StatusBadRequest)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(query.Get("dry_run"))
+	points := 0
+	if query.Get("points") != "" {
+		points, err = strconv.Atoi(query.Get("points"))
+		if err != nil || points <= 0 {
+			http.Error(w, "invalid points", http.StatusBadRequest)
+			return
+		}
+	}
+
+	imp, err := quizio.Decode(format, body)
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if title := query.Get("title"); title != "" {
+		imp.Quiz.Title = title
+	}
+	if category := query.Get("category"); category != "" {
+		imp.Quiz.Category = category
+	}
+	if difficulty := query.Get("difficulty"); difficulty != "" {
+		imp.Quiz.Difficulty = difficulty
+	}
+	if description := query.Get("description"); description != "" {
+		imp.Quiz.Description = description
+	}
+	if points > 0 {
+		imp.Quiz.Points = points
+	}
+	if imp.Quiz.Points <= 0 {
+		imp.Quiz.Points = 100 // Default
+	}
+
+	report, err := h.quizService.ImportQuiz(r.Context(), userID, imp, dryRun)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusCreated
+	switch {
+	case !report.Valid:
+		status = http.StatusUnprocessableEntity
+	case dryRun:
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// ExportQuiz downloads a quiz with its answer keys as ?format=json|csv|gift|xml (json by default)
+func (h *QuizHandler) ExportQuiz(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	format := quizio.FormatJSON
+	if f := r.URL.Query().Get("format"); f != "" {
+		format, err = quizio.ParseFormat(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	quiz, err := h.quizService.ExportQuiz(r.Context(), actor, id)
+	if err != nil {
+		http.Error(w, err.Error(), quizErrorStatus(err))
+		return
+	}
+
+	// Encode up front so a question the format can't express is still reported as an error
+	var buf bytes.Buffer
+	if err := quizio.Encode(format, &buf, quiz); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, quizio.ErrUnsupported) {
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(quiz.Title)+"."+format.Extension()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// exportFilename turns a quiz title into a safe download name.
+func exportFilename(title string) string {
+	name := strings.Trim(unsafeFilenameChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if name == "" {
+		return "quiz"
+	}
+	return name
+}
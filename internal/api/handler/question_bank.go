@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type QuestionBankHandler struct {
+	questionBank *service.QuestionBankService
+}
+
+func NewQuestionBankHandler(questionBank *service.QuestionBankService) *QuestionBankHandler {
+	return &QuestionBankHandler{
+		questionBank: questionBank,
+	}
+}
+
+// CreateQuestion adds a question to the caller's bank: {"question": {...}, "tags": [...], "difficulty": "..."}
+func (h *QuestionBankHandler) CreateQuestion(w http.ResponseWriter, r *http.Request) {
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var question model.BankQuestion
+	if err := json.NewDecoder(r.Body).Decode(&question); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.questionBank.CreateQuestion(r.Context(), actor.UserID, &question)
+	if err != nil {
+		writeBankError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListQuestions returns the caller's bank filtered with ?tags=&difficulty=, paginated with ?page=&limit=
+func (h *QuestionBankHandler) ListQuestions(w http.ResponseWriter, r *http.Request) {
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	params := r.URL.Query()
+	var tags []string
+	for _, t := range params["tags"] {
+		tags = append(tags, strings.Split(t, ",")...)
+	}
+	page, limit := pagination(r, 20, 100)
+	questions, total, err := h.questionBank.ListQuestions(r.Context(), actor, tags, params.Get("difficulty"), page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"questions": questions,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+func (h *QuestionBankHandler) GetQuestion(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid question id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	question, err := h.questionBank.GetQuestion(r.Context(), actor, id)
+	if err != nil {
+		writeBankError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(question)
+}
+
+func (h *QuestionBankHandler) UpdateQuestion(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid question id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var question model.BankQuestion
+	if err := json.NewDecoder(r.Body).Decode(&question); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.questionBank.UpdateQuestion(r.Context(), actor, id, &question)
+	if err != nil {
+		writeBankError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *QuestionBankHandler) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid question id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.questionBank.DeleteQuestion(r.Context(), actor, id); err != nil {
+		writeBankError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeBankError writes a question bank error. Validation errors are sent as 422
+// with the list of fields at fault, like quiz ones.
+func writeBankError(w http.ResponseWriter, err error) {
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		writeQuizError(w, err)
+		return
+	}
+	http.Error(w, err.Error(), bankErrorStatus(err))
+}
+
+// bankErrorStatus maps question bank errors to HTTP status codes.
+func bankErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidQuiz):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrBankQuestionNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -2,8 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
@@ -27,12 +31,13 @@ func NewQuizHandler(quizService *service.QuizService, userService *service.UserS
 
 func (h *QuizHandler) GenerateQuiz(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Title        string `json:"title"`
-		Category     string `json:"category"`
-		Difficulty   string `json:"difficulty"`
-		Description  string `json:"description"`
-		NumQuestions int    `json:"num_questions"`
-		Points       int    `json:"points"`
+		Title         string   `json:"title"`
+		Category      string   `json:"category"`
+		Difficulty    string   `json:"difficulty"`
+		Description   string   `json:"description"`
+		NumQuestions  int      `json:"num_questions"`
+		Points        int      `json:"points"`
+		QuestionTypes []string `json:"question_types"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -56,9 +61,10 @@ func (h *QuizHandler) GenerateQuiz(w http.ResponseWriter, r *http.Request) {
 		req.Description,
 		req.NumQuestions,
 		req.Points,
+		req.QuestionTypes,
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeQuizError(w, err)
 		return
 	}
 
@@ -68,15 +74,22 @@ func (h *QuizHandler) GenerateQuiz(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *QuizHandler) CreateQuiz(w http.ResponseWriter, r *http.Request) {
+	// User ID is already set in context by Authenticate middleware
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
 	var quiz model.Quiz
 	if err := json.NewDecoder(r.Body).Decode(&quiz); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	created, err := h.quizService.CreateQuiz(r.Context(), quiz.Title, quiz.Category, quiz.Difficulty, quiz.Questions, quiz.Points)
+	created, err := h.quizService.CreateQuiz(r.Context(), userID, &quiz)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeQuizError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
@@ -84,21 +97,10 @@ func (h *QuizHandler) CreateQuiz(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *QuizHandler) GetQuizzesGroupedByCategory(w http.ResponseWriter, r *http.Request) {
-	// Try to get userID if authenticated
-	var userID primitive.ObjectID
-	tokenString := utils.GetTokenFromRequest(r)
-	if tokenString != "" {
-		token, err := utils.TokenValidator(tokenString)
-		if err == nil && token.Valid {
-			if claims, ok := token.Claims.(jwt.MapClaims); ok {
-				if userIDHex, ok := claims["user_id"].(string); ok {
-					userID, _ = primitive.ObjectIDFromHex(userIDHex)
-				}
-			}
-		}
-	}
+	// Identify the caller if authenticated
+	actor := optionalActor(r)
 
-	grouped, err := h.quizService.GetQuizzesGroupedByCategory(r.Context(), userID)
+	grouped, err := h.quizService.GetQuizzesGroupedByCategory(r.Context(), actor)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -108,28 +110,117 @@ func (h *QuizHandler) GetQuizzesGroupedByCategory(w http.ResponseWriter, r *http
 }
 
 func (h *QuizHandler) GetQuizzes(w http.ResponseWriter, r *http.Request) {
-	// Try to get userID if authenticated (optional auth)
-	var userID primitive.ObjectID
-	tokenString := utils.GetTokenFromRequest(r)
-	if tokenString != "" {
-		token, err := utils.TokenValidator(tokenString)
-		if err == nil && token.Valid {
-			if claims, ok := token.Claims.(jwt.MapClaims); ok {
-				if userIDHex, ok := claims["user_id"].(string); ok {
-					userID, _ = primitive.ObjectIDFromHex(userIDHex)
-				}
-			}
+	// Identify the caller if authenticated (optional auth)
+	actor := optionalActor(r)
+
+	query, err := catalogQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Attempted != nil && actor.UserID.IsZero() {
+		http.Error(w, "authentication required to filter on attempted quizzes", http.StatusUnauthorized)
+		return
+	}
+
+	// Pass the caller to the service to decorate quizzes with Attempted status
+	quizzes, total, err := h.quizService.ListQuizzes(r.Context(), actor, query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidQuery) {
+			status = http.StatusBadRequest
 		}
+		http.Error(w, err.Error(), status)
+		return
 	}
 
-	// Pass userID to the service to decorate quizzes with Attempted status
-	quizzes, err := h.quizService.GetQuizzes(r.Context(), userID)
+	var items any
+	if query.Summary {
+		summaries := make([]model.QuizSummary, 0, len(quizzes))
+		for _, q := range quizzes {
+			summaries = append(summaries, q.Summary())
+		}
+		items = summaries
+	} else {
+		public := make([]model.PublicQuiz, 0, len(quizzes))
+		for _, q := range quizzes {
+			public = append(public, q.Public())
+		}
+		items = public
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"quizzes": items,
+		"total":   total,
+		"page":    query.Page,
+		"limit":   query.Limit,
+	})
+}
+
+// SearchQuizzes runs a full-text search: ?q=&page=&limit=
+func (h *QuizHandler) SearchQuizzes(w http.ResponseWriter, r *http.Request) {
+	// Identify the caller if authenticated (optional auth)
+	actor := optionalActor(r)
+
+	page, limit := pagination(r, 20, 100)
+	results, total, err := h.quizService.SearchQuizzes(r.Context(), actor, r.URL.Query().Get("q"), page, limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidQuery) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(quizzes)
+	json.NewEncoder(w).Encode(map[string]any{
+		"results": results,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// catalogQuery reads the catalog filters, sort and pagination from the query string:
+// ?category=&difficulty=&tags=a,b&creator=&attempted=true|false&sort=newest|popular|rating&view=summary|full&page=&limit=
+func catalogQuery(r *http.Request) (service.QuizQuery, error) {
+	params := r.URL.Query()
+	query := service.QuizQuery{
+		Category:   params.Get("category"),
+		Difficulty: params.Get("difficulty"),
+		Sort:       params.Get("sort"),
+	}
+	query.Page, query.Limit = pagination(r, 20, 100)
+
+	for _, tags := range params["tags"] {
+		query.Tags = append(query.Tags, strings.Split(tags, ",")...)
+	}
+	if creator := params.Get("creator"); creator != "" {
+		id, err := primitive.ObjectIDFromHex(creator)
+		if err != nil {
+			return query, errors.New("invalid creator id")
+		}
+		query.CreatorID = id
+	}
+	if attempted := params.Get("attempted"); attempted != "" {
+		v, err := strconv.ParseBool(attempted)
+		if err != nil {
+			return query, errors.New("attempted must be true or false")
+		}
+		query.Attempted = &v
+	}
+	switch params.Get("view") {
+	case "", "full":
+	case "summary":
+		query.Summary = true
+	default:
+		return query, errors.New("view must be summary or full")
+	}
+	return query, nil
 }
 
 func (h *QuizHandler) GetQuiz(w http.ResponseWriter, r *http.Request) {
@@ -141,22 +232,23 @@ func (h *QuizHandler) GetQuiz(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	quiz, err := h.quizService.GetQuizByID(r.Context(), id)
+	// Unlisted and private quizzes are opened with ?share=<token>
+	quiz, err := h.quizService.GetQuizForViewer(r.Context(), optionalActor(r), id, r.URL.Query().Get("share"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		http.Error(w, err.Error(), quizErrorStatus(err))
 		return
 	}
-	
+
 	// Debug: Log quiz data before sending
 	fmt.Printf("Quiz fetched - ID: %s, Title: %s, Questions count: %d\n", quiz.ID.Hex(), quiz.Title, len(quiz.Questions))
 	if len(quiz.Questions) > 0 {
-		fmt.Printf("First question - ID: %s, Text: %s, Options count: %d\n", 
+		fmt.Printf("First question - ID: %s, Text: %s, Options count: %d\n",
 			quiz.Questions[0].ID.Hex(), quiz.Questions[0].Text, len(quiz.Questions[0].Options))
 	}
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(quiz)
+	json.NewEncoder(w).Encode(quiz.Public())
 }
 
 func (h *QuizHandler) SubmitQuizResult(w http.ResponseWriter, r *http.Request) {
@@ -206,33 +298,361 @@ func (h *QuizHandler) SubmitQuiz(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// User ID is already set in context by Authenticate middleware
-	userIDHex := utils.GetUserId(r.Context())
-	if userIDHex == "" {
+	// User ID and role are already set in context by Authenticate middleware
+	if utils.GetUserId(r.Context()) == "" {
 		http.Error(w, "user not authenticated", http.StatusUnauthorized)
 		return
 	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	userID, err := primitive.ObjectIDFromHex(userIDHex)
+	var req struct {
+		AttemptID string         `json:"attempt_id"`
+		Answers   map[string]any `json:"answers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attemptID, err := primitive.ObjectIDFromHex(req.AttemptID)
 	if err != nil {
-		http.Error(w, "invalid user ID", http.StatusBadRequest)
+		http.Error(w, "invalid attempt id", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.quizService.SubmitQuiz(r.Context(), actor, quizID, attemptID, req.Answers)
+	if err != nil {
+		http.Error(w, err.Error(), attemptErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// SubmitPractice grades a practice attempt: same body and response as SubmitQuiz,
+// but the user's stats and the leaderboard are left untouched.
+func (h *QuizHandler) SubmitPractice(w http.ResponseWriter, r *http.Request) {
+	quizID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		AttemptID string         `json:"attempt_id"`
+		Answers   map[string]any `json:"answers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	attemptID, err := primitive.ObjectIDFromHex(req.AttemptID)
+	if err != nil {
+		http.Error(w, "invalid attempt id", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.quizService.SubmitPractice(r.Context(), actor, quizID, attemptID, req.Answers)
+	if err != nil {
+		http.Error(w, err.Error(), attemptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetMyQuizzes returns the quizzes created by the authenticated user, answer keys included
+func (h *QuizHandler) GetMyQuizzes(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		http.Error(w, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	quizzes, err := h.quizService.GetMyQuizzes(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quizzes)
+}
+
+// UpdateQuiz replaces the quiz content, recording a new version
+func (h *QuizHandler) UpdateQuiz(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var quiz model.Quiz
+	if err := json.NewDecoder(r.Body).Decode(&quiz); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.quizService.UpdateQuiz(r.Context(), actor, id, &quiz)
+	if err != nil {
+		writeQuizError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+// PatchQuiz updates only the fields present in the body, recording a new version
+func (h *QuizHandler) PatchQuiz(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var patch service.QuizPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.quizService.PatchQuiz(r.Context(), actor, id, patch)
+	if err != nil {
+		writeQuizError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *QuizHandler) DeleteQuiz(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.quizService.DeleteQuiz(r.Context(), actor, id); err != nil {
+		http.Error(w, err.Error(), quizErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListVersions returns the version history of a quiz, newest first
+func (h *QuizHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	versions, err := h.quizService.ListVersions(r.Context(), actor, id)
+	if err != nil {
+		http.Error(w, err.Error(), quizErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(versions)
+}
+
+// RollbackQuiz restores an earlier version as a new version
+func (h *QuizHandler) RollbackQuiz(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil || version < 1 {
+		http.Error(w, "invalid version", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	quiz, err := h.quizService.RollbackQuiz(r.Context(), actor, id, version)
+	if err != nil {
+		writeQuizError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quiz)
+}
+
+// TransitionQuiz changes the publication status of a quiz: {"status": "in_review", "publish_at": "..."}
+func (h *QuizHandler) TransitionQuiz(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
 	var req struct {
-		Answers map[string]string `json:"answers"`
+		Status    model.QuizStatus `json:"status"`
+		PublishAt *time.Time       `json:"publish_at"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	score, err := h.quizService.SubmitQuiz(r.Context(), userID, quizID, req.Answers)
+	quiz, err := h.quizService.TransitionQuiz(r.Context(), actor, id, req.Status, req.PublishAt)
+	if err != nil {
+		http.Error(w, err.Error(), quizErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quiz)
+}
+
+// ListReviewQueue returns the quizzes waiting for review, paginated with ?page=&limit=
+func (h *QuizHandler) ListReviewQueue(w http.ResponseWriter, r *http.Request) {
+	page, limit := pagination(r, 20, 100)
+	quizzes, total, err := h.quizService.ListReviewQueue(r.Context(), page, limit)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	summaries := make([]model.QuizSummary, 0, len(quizzes))
+	for _, q := range quizzes {
+		summaries = append(summaries, q.Summary())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]int{"score": score})
+	json.NewEncoder(w).Encode(map[string]any{
+		"quizzes": summaries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// writeQuizError writes a quiz management error. Structural validation errors are
+// sent as 422 with the list of fields at fault.
+func writeQuizError(w http.ResponseWriter, err error) {
+	var verr *service.ValidationError
+	if !errors.As(err, &verr) {
+		http.Error(w, err.Error(), quizErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error":  service.ErrInvalidQuiz.Error(),
+		"errors": verr.Errors,
+	})
+}
+
+// quizErrorStatus maps quiz management errors to HTTP status codes.
+func quizErrorStatus(err error) int {
+	var verr *service.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrInvalidQuiz):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrQuizNotFound), errors.Is(err, service.ErrVersionNotFound), errors.Is(err, service.ErrShareLinkNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrNotQuizOwner), errors.Is(err, service.ErrShareLinkInvalid):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUpgradeRequired):
+		return http.StatusPaymentRequired
+	case errors.Is(err, service.ErrQuizConflict), errors.Is(err, service.ErrInvalidTransition):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// actorFromRequest identifies the authenticated user behind a management request.
+func actorFromRequest(r *http.Request) (service.Actor, error) {
+	userID, err := primitive.ObjectIDFromHex(utils.GetUserId(r.Context()))
+	if err != nil {
+		return service.Actor{}, errors.New("invalid user ID")
+	}
+	return service.NewActor(userID, utils.GetRole(r.Context())), nil
+}
+
+// optionalActor identifies the caller when a valid token is sent. Anonymous
+// callers get a zero Actor.
+func optionalActor(r *http.Request) service.Actor {
+	tokenString := utils.GetTokenFromRequest(r)
+	if tokenString == "" {
+		return service.Actor{}
+	}
+	token, err := utils.TokenValidator(tokenString)
+	if err != nil || !token.Valid {
+		return service.Actor{}
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return service.Actor{}
+	}
+	userIDHex, _ := claims["user_id"].(string)
+	userID, err := primitive.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		return service.Actor{}
+	}
+	claimed, _ := claims["role"].(string)
+	role, err := utils.VerifiedRole(r.Context(), userIDHex, claimed)
+	if err != nil {
+		return service.Actor{}
+	}
+	return service.NewActor(userID, role)
 }
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sachinggsingh/quiz/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RatingHandler struct {
+	ratingService *service.RatingService
+}
+
+func NewRatingHandler(ratingService *service.RatingService) *RatingHandler {
+	return &RatingHandler{
+		ratingService: ratingService,
+	}
+}
+
+// RateQuiz rates a completed quiz: {"stars": 4, "review": "..."}, the review being optional.
+// Rating again replaces the previous rating.
+func (h *RatingHandler) RateQuiz(w http.ResponseWriter, r *http.Request) {
+	quizID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+	actor, err := actorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Stars  int    `json:"stars"`
+		Review string `json:"review"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.ratingService.RateQuiz(r.Context(), actor.UserID, quizID, req.Stars, req.Review)
+	if err != nil {
+		http.Error(w, err.Error(), ratingErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListReviews returns a quiz's ratings, most recent first, paginated with ?page=&limit=.
+// ?reviewed=true keeps only the ratings with a review.
+func (h *RatingHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	quizID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid quiz id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	page, limit := pagination(r, 20, 100)
+	reviews, total, err := h.ratingService.ListReviews(r.Context(), optionalActor(r), quizID, query.Get("share"), query.Get("reviewed") == "true", page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), ratingErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"reviews": reviews,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// ratingErrorStatus maps rating errors to HTTP status codes.
+func ratingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidRating):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrRatingNotAllowed):
+		return http.StatusForbidden
+	default:
+		return quizErrorStatus(err)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	"github.com/sachinggsingh/quiz/config"
 	"github.com/sachinggsingh/quiz/internal/api/handler"
+	"github.com/sachinggsingh/quiz/internal/model"
 	"github.com/sachinggsingh/quiz/internal/repo"
 	"github.com/sachinggsingh/quiz/internal/service"
 	"github.com/sachinggsingh/quiz/internal/utils"
@@ -50,8 +52,13 @@ func NewServer(db *mongo.Database, env *config.Env) *Server {
 	// 1. Repositories
 	userRepo := repo.NewUserRepo(db)
 	quizRepo := repo.NewQuizRepo(db)
+	quizVersionRepo := repo.NewQuizVersionRepo(db)
 	commentRepo := repo.NewCommentRepo(db)
 	subscriptionRepo := repo.NewSubscription(db)
+	attemptRepo := repo.NewAttemptRepo(db)
+	questionBankRepo := repo.NewQuestionBankRepo(db)
+	shareLinkRepo := repo.NewShareLinkRepo(db)
+	ratingRepo := repo.NewRatingRepo(db)
 
 	// 2. Services
 	wsHub := ws.NewHub(10) // 10 workers for message processing
@@ -59,9 +66,17 @@ func NewServer(db *mongo.Database, env *config.Env) *Server {
 	stripeClient := config.NewStripeClient()
 	leaderboardService := service.NewLeaderboardService(userRepo, &wsLeaderboardBroadcaster{hub: wsHub})
 	userService := service.NewUserService(userRepo)
-	quizService := service.NewQuizService(quizRepo, userRepo, leaderboardService, notificationService)
-	commentService := service.NewCommentService(commentRepo)
+	// Privileged token claims are checked against the stored role on every request
+	utils.UseRoleLookup(userService.CurrentRole)
 	subscriptionService := service.NewSubscriptionService(subscriptionRepo, stripeClient, userRepo)
+	questionBankService := service.NewQuestionBankService(questionBankRepo)
+	shareService := service.NewShareService(shareLinkRepo, quizRepo)
+	quizService := service.NewQuizService(quizRepo, userRepo, attemptRepo, quizVersionRepo, questionBankService, shareService, subscriptionService, leaderboardService, notificationService)
+	attemptService := service.NewAttemptService(attemptRepo, quizRepo, userRepo, quizVersionRepo, questionBankService, shareService, subscriptionService)
+	go quizService.RunPublishScheduler(context.Background(), 30*time.Second)
+	go quizService.RunAttemptSweeper(context.Background(), time.Minute)
+	commentService := service.NewCommentService(commentRepo)
+	ratingService := service.NewRatingService(ratingRepo, quizRepo, userRepo, shareService)
 
 	// Wire up NotificationService to Hub
 	go func() {
@@ -92,9 +107,17 @@ func NewServer(db *mongo.Database, env *config.Env) *Server {
 	// 3. Handlers
 	userHandler := handler.NewRestHandler(userService)
 	quizHandler := handler.NewQuizHandler(quizService, userService)
+	attemptHandler := handler.NewAttemptHandler(attemptService)
 	commentHandler := handler.NewCommentHandler(commentService, userService)
 	subscriptionHandler := handler.NewSubscriptonHandler(subscriptionService, subscriptionRepo)
-	wsHandler := ws.NewHandler(wsHub, leaderboardService, subscriptionService)
+	adminHandler := handler.NewAdminHandler(userService)
+	questionBankHandler := handler.NewQuestionBankHandler(questionBankService)
+	shareHandler := handler.NewShareHandler(shareService)
+	ratingHandler := handler.NewRatingHandler(ratingService)
+	wsHandler := ws.NewHandler(wsHub, leaderboardService, subscriptionService, quizService)
+
+	// Roles allowed to author quizzes
+	quizAuthors := []string{string(model.RoleCreator), string(model.RoleAdmin)}
 
 	// 4. Router
 	r := mux.NewRouter()
@@ -106,13 +129,45 @@ func NewServer(db *mongo.Database, env *config.Env) *Server {
 	r.HandleFunc("/logout", userHandler.Logout).Methods("POST")
 	r.HandleFunc("/refresh-token", userHandler.RefreshToken).Methods("POST")
 	r.HandleFunc("/me", utils.Authenticate(userHandler.GetMe)).Methods("GET")
+	r.HandleFunc("/me/quizzes", utils.Authenticate(quizHandler.GetMyQuizzes)).Methods("GET")
+	r.HandleFunc("/me/attempts", utils.Authenticate(attemptHandler.ListAttempts)).Methods("GET")
+	r.HandleFunc("/me/attempts/{id}", utils.Authenticate(attemptHandler.GetAttempt)).Methods("GET")
+	r.HandleFunc("/me/attempts/{id}/review", utils.Authenticate(attemptHandler.ReviewAttempt)).Methods("GET")
+	r.HandleFunc("/me/attempts/{id}/resume", utils.Authenticate(attemptHandler.ResumeAttempt)).Methods("GET")
+	r.HandleFunc("/me/attempts/{id}/answers", utils.Authenticate(attemptHandler.SaveAnswers)).Methods("PUT")
+	r.HandleFunc("/me/attempts/{id}/hints", utils.Authenticate(attemptHandler.RevealHint)).Methods("POST")
+	// admin routes
+	r.HandleFunc("/admin/users/{id}/role", utils.RequireRoles(adminHandler.UpdateUserRole, string(model.RoleAdmin))).Methods("PUT")
 	// quiz routes
 	r.HandleFunc("/quizzes/categories", quizHandler.GetQuizzesGroupedByCategory).Methods("GET")
-	r.HandleFunc("/quizzes/generate", utils.Authenticate(quizHandler.GenerateQuiz)).Methods("POST")
-	r.HandleFunc("/quizzes", quizHandler.CreateQuiz).Methods("POST")
+	r.HandleFunc("/quizzes/search", quizHandler.SearchQuizzes).Methods("GET") // before /quizzes/{id}
+	r.HandleFunc("/quizzes/review", utils.RequireRoles(quizHandler.ListReviewQueue, string(model.RoleModerator), string(model.RoleAdmin))).Methods("GET")
+	r.HandleFunc("/quizzes/generate", utils.RequireRoles(quizHandler.GenerateQuiz, quizAuthors...)).Methods("POST")
+	r.HandleFunc("/quizzes/import", utils.RequireRoles(quizHandler.ImportQuiz, quizAuthors...)).Methods("POST")
+	r.HandleFunc("/quizzes", utils.RequireRoles(quizHandler.CreateQuiz, quizAuthors...)).Methods("POST")
 	r.HandleFunc("/quizzes", quizHandler.GetQuizzes).Methods("GET")
 	r.HandleFunc("/quizzes/{id}", quizHandler.GetQuiz).Methods("GET")
+	r.HandleFunc("/quizzes/{id}", utils.Authenticate(quizHandler.UpdateQuiz)).Methods("PUT")
+	r.HandleFunc("/quizzes/{id}", utils.Authenticate(quizHandler.PatchQuiz)).Methods("PATCH")
+	r.HandleFunc("/quizzes/{id}", utils.Authenticate(quizHandler.DeleteQuiz)).Methods("DELETE")
+	r.HandleFunc("/quizzes/{id}/status", utils.Authenticate(quizHandler.TransitionQuiz)).Methods("POST")
+	r.HandleFunc("/quizzes/{id}/export", utils.Authenticate(quizHandler.ExportQuiz)).Methods("GET")
+	r.HandleFunc("/quizzes/{id}/versions", utils.Authenticate(quizHandler.ListVersions)).Methods("GET")
+	r.HandleFunc("/quizzes/{id}/versions/{version}/rollback", utils.Authenticate(quizHandler.RollbackQuiz)).Methods("POST")
+	r.HandleFunc("/quizzes/{id}/shares", utils.Authenticate(shareHandler.CreateLink)).Methods("POST")
+	r.HandleFunc("/quizzes/{id}/shares", utils.Authenticate(shareHandler.ListLinks)).Methods("GET")
+	r.HandleFunc("/quizzes/{id}/shares/{share_id}", utils.Authenticate(shareHandler.RevokeLink)).Methods("DELETE")
+	r.HandleFunc("/quizzes/{id}/rating", utils.Authenticate(ratingHandler.RateQuiz)).Methods("PUT")
+	r.HandleFunc("/quizzes/{id}/reviews", ratingHandler.ListReviews).Methods("GET")
+	r.HandleFunc("/quizzes/{id}/attempts", utils.Authenticate(attemptHandler.StartAttempt)).Methods("POST")
 	r.HandleFunc("/quizzes/{id}/submit", utils.Authenticate(quizHandler.SubmitQuiz)).Methods("POST")
+	r.HandleFunc("/quizzes/{id}/practice", utils.Authenticate(quizHandler.SubmitPractice)).Methods("POST")
+	// question bank routes
+	r.HandleFunc("/bank/questions", utils.RequireRoles(questionBankHandler.CreateQuestion, quizAuthors...)).Methods("POST")
+	r.HandleFunc("/bank/questions", utils.RequireRoles(questionBankHandler.ListQuestions, quizAuthors...)).Methods("GET")
+	r.HandleFunc("/bank/questions/{id}", utils.RequireRoles(questionBankHandler.GetQuestion, quizAuthors...)).Methods("GET")
+	r.HandleFunc("/bank/questions/{id}", utils.RequireRoles(questionBankHandler.UpdateQuestion, quizAuthors...)).Methods("PUT")
+	r.HandleFunc("/bank/questions/{id}", utils.RequireRoles(questionBankHandler.DeleteQuestion, quizAuthors...)).Methods("DELETE")
 	// comment routes
 	r.HandleFunc("/comments", utils.Authenticate(commentHandler.CreateComment)).Methods("POST")
 	r.HandleFunc("/comments", commentHandler.GetComments).Methods("GET")
@@ -130,7 +185,7 @@ func NewServer(db *mongo.Database, env *config.Env) *Server {
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{frontendURL, "http://localhost:3000", "http://localhost:3001"}, // use the env var for frontend url in prod
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Authorization", "Content-Type", "Cookie"},
 		ExposedHeaders:   []string{"Set-Cookie"},
 		AllowCredentials: true,
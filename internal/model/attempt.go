@@ -0,0 +1,147 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttemptStatus is the lifecycle state of a quiz attempt.
+type AttemptStatus string
+
+const (
+	AttemptInProgress AttemptStatus = "in_progress"
+	AttemptGrading    AttemptStatus = "grading" // claimed by a submission, being counted
+	AttemptSubmitted  AttemptStatus = "submitted"
+	AttemptExpired    AttemptStatus = "expired"
+)
+
+// Attempt is a server-tracked run of a quiz by a user. The server timestamps
+// the start so time limits can be enforced on submission.
+type Attempt struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	QuizID      primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`
+	QuizTitle   string             `bson:"quiz_title" json:"quiz_title"`
+	QuizVersion int                `bson:"quiz_version,omitempty" json:"quiz_version,omitempty"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Status      AttemptStatus      `bson:"status" json:"status"`
+	Practice    bool               `bson:"practice,omitempty" json:"practice,omitempty"`     // graded, but never counts toward stats
+	TimeLimit   int                `bson:"time_limit,omitempty" json:"time_limit,omitempty"` // seconds, 0 means untimed
+	StartedAt   time.Time          `bson:"started_at" json:"started_at"`
+	ExpiresAt   *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	EndedAt     *time.Time         `bson:"ended_at,omitempty" json:"ended_at,omitempty"`
+
+	// Seed draws the attempt's bank questions and its question and option order.
+	// Questions are only stored when the quiz draws from the question bank.
+	Seed      int64      `bson:"seed,omitempty" json:"-"`
+	Questions []Question `bson:"questions,omitempty" json:"-"`
+	// QuestionOrder[i] is the quiz index of the i-th question shown, and
+	// OptionOrders[q][i] the original index of the i-th option shown for quiz
+	// question q. Empty means the authored order.
+	QuestionOrder []int   `bson:"question_order,omitempty" json:"-"`
+	OptionOrders  [][]int `bson:"option_orders,omitempty" json:"-"`
+	// Served is the player view of the questions in the attempt's order. Not stored.
+	Served        []PublicQuestion `bson:"-" json:"questions,omitempty"`
+	QuestionCount int              `bson:"question_count,omitempty" json:"question_count,omitempty"`
+
+	// Answers saved while the attempt is open, keyed like a submission. They are
+	// submitted with the attempt if it is abandoned past its deadline.
+	Answers   map[string]any `bson:"answers,omitempty" json:"answers,omitempty"`
+	SavedAt   *time.Time     `bson:"saved_at,omitempty" json:"saved_at,omitempty"`
+	Remaining *int           `bson:"-" json:"remaining,omitempty"` // seconds left, filled for open timed attempts
+	// Number of hints revealed per question, keyed by shown position like the answers
+	HintsUsed map[string]int `bson:"hints_used,omitempty" json:"hints_used,omitempty"`
+
+	// Filled in when the attempt is graded
+	Score      int              `bson:"score" json:"score"`
+	SpeedBonus int              `bson:"speed_bonus,omitempty" json:"speed_bonus,omitempty"`
+	Correct    int              `bson:"correct" json:"correct"`
+	Percentage int              `bson:"percentage" json:"percentage"`
+	Results    []QuestionResult `bson:"results,omitempty" json:"results,omitempty"`
+	// Submitted by the server with the saved answers once the deadline passed
+	AutoSubmitted bool `bson:"auto_submitted,omitempty" json:"auto_submitted,omitempty"`
+	// Times the server failed to close the attempt once it was abandoned
+	SweepFailures int `bson:"sweep_failures,omitempty" json:"-"`
+}
+
+// QuestionResult is the graded outcome of one question in an attempt. It is also
+// the post-submission review, so it carries the correct answer.
+type QuestionResult struct {
+	QuestionID    primitive.ObjectID `bson:"question_id" json:"question_id"`
+	Index         int                `bson:"index" json:"index"`
+	Text          string             `bson:"text" json:"text"`
+	Options       []string           `bson:"options" json:"options"`
+	Type          QuestionType       `bson:"type" json:"type"`
+	Answer        any                `bson:"answer,omitempty" json:"answer"`
+	CorrectAnswer any                `bson:"correct_answer" json:"correct_answer"`
+	Correct       bool               `bson:"correct" json:"correct"`
+	Credit        float64            `bson:"credit" json:"credit"`
+	Points        float64            `bson:"points" json:"points"`
+	Explanation   string             `bson:"explanation,omitempty" json:"explanation,omitempty"`
+	Reference     string             `bson:"reference,omitempty" json:"reference,omitempty"`
+	HintsUsed     int                `bson:"hints_used,omitempty" json:"hints_used,omitempty"`
+}
+
+// AbandonedAt returns when the attempt stopped counting as played: its deadline
+// if it is timed, otherwise the last time answers were saved or it was started.
+func (a *Attempt) AbandonedAt() time.Time {
+	switch {
+	case a.ExpiresAt != nil:
+		return *a.ExpiresAt
+	case a.SavedAt != nil:
+		return *a.SavedAt
+	}
+	return a.StartedAt
+}
+
+// RemainingAt fills Remaining with the seconds left at now, for timed attempts.
+func (a *Attempt) RemainingAt(now time.Time) {
+	if a.ExpiresAt == nil {
+		return
+	}
+	remaining := max(int(a.ExpiresAt.Sub(now).Seconds()), 0)
+	a.Remaining = &remaining
+}
+
+// QuestionAt returns the quiz index of the question shown at position i.
+func (a *Attempt) QuestionAt(i int) int {
+	if i < len(a.QuestionOrder) {
+		return a.QuestionOrder[i]
+	}
+	return i
+}
+
+// OptionOrder returns the order the options of quiz question q are shown in,
+// nil when they keep the authored order.
+func (a *Attempt) OptionOrder(q int) []int {
+	if q < len(a.OptionOrders) && len(a.OptionOrders[q]) > 0 {
+		return a.OptionOrders[q]
+	}
+	return nil
+}
+
+// Displayed returns the questions the way the attempt shows them: in its
+// question order, with options in its option order and the answer keys
+// pointing at the shown positions.
+func (a *Attempt) Displayed(questions []Question) []Question {
+	displayed := make([]Question, 0, len(questions))
+	for i := range questions {
+		q := a.QuestionAt(i)
+		displayed = append(displayed, questions[q].Displayed(a.OptionOrder(q)))
+	}
+	return displayed
+}
+
+// Serve fills Served with the player view of the questions in the attempt's order,
+// with the hints revealed so far.
+func (a *Attempt) Serve(questions []Question) {
+	a.Served = make([]PublicQuestion, 0, len(questions))
+	for i, q := range a.Displayed(questions) {
+		served := q.Public()
+		if used := min(a.HintsUsed[strconv.Itoa(i)], len(q.Hints)); used > 0 {
+			served.Hints = q.Hints[:used]
+		}
+		a.Served = append(a.Served, served)
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestRemainingAt(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      *int
+	}{
+		{"untimed", nil, nil},
+		{"running", at(90*time.Second + 500*time.Millisecond), ptr(90)},
+		{"at the deadline", at(0), ptr(0)},
+		{"past the deadline", at(-time.Minute), ptr(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Attempt{ExpiresAt: tt.expiresAt}
+			a.RemainingAt(now)
+			if (a.Remaining == nil) != (tt.want == nil) || a.Remaining != nil && *a.Remaining != *tt.want {
+				t.Errorf("remaining is %v, want %v", a.Remaining, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbandonedAt(t *testing.T) {
+	started := time.Now().Add(-time.Hour)
+	saved := started.Add(10 * time.Minute)
+	deadline := started.Add(30 * time.Minute)
+
+	tests := []struct {
+		name    string
+		attempt Attempt
+		want    time.Time
+	}{
+		{"timed", Attempt{StartedAt: started, SavedAt: &saved, ExpiresAt: &deadline}, deadline},
+		{"untimed with saved answers", Attempt{StartedAt: started, SavedAt: &saved}, saved},
+		{"untimed without answers", Attempt{StartedAt: started}, started},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.attempt.AbandonedAt(); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
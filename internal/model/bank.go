@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BankQuestion is a reusable question kept in its author's question bank.
+// Quizzes pull bank questions through their rules.
+type BankQuestion struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Question   Question           `bson:"question" json:"question"`
+	Tags       []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Difficulty string             `bson:"difficulty,omitempty" json:"difficulty,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// QuestionRule draws Count random questions from the quiz owner's bank, among
+// the ones carrying every tag and matching the difficulty when set.
+type QuestionRule struct {
+	Count      int      `bson:"count" json:"count"`
+	Tags       []string `bson:"tags,omitempty" json:"tags,omitempty"`
+	Difficulty string   `bson:"difficulty,omitempty" json:"difficulty,omitempty"`
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HintType selects what a hint reveals.
+type HintType string
+
+const (
+	HintEliminate HintType = "eliminate" // rules out a wrong option
+	HintClue      HintType = "clue"      // shows a text clue
+)
+
+// Hint is one of a question's hints, revealed in order during an attempt at the
+// cost of points.
+type Hint struct {
+	Type   HintType `bson:"type" json:"type"`
+	Text   string   `bson:"text,omitempty" json:"text,omitempty"`     // clue
+	Option int      `bson:"option,omitempty" json:"option,omitempty"` // eliminate: index of the wrong option
+}
+
+// validateHints checks every hint of the question against its type and answer key.
+func (q *Question) validateHints() error {
+	var eliminated []int
+	for i, h := range q.Hints {
+		switch h.Type {
+		case HintClue:
+			if strings.TrimSpace(h.Text) == "" {
+				return fieldError(fmt.Sprintf("hints[%d].text", i), "is required")
+			}
+		case HintEliminate:
+			switch q.Kind() {
+			case QuestionSingleChoice, QuestionMultiSelect:
+			default:
+				return fieldError(fmt.Sprintf("hints[%d].type", i), "can't eliminate options of %s questions", q.Kind())
+			}
+			if h.Option < 0 || h.Option >= len(q.Options) {
+				return fieldError(fmt.Sprintf("hints[%d].option", i), "out of range")
+			}
+			if q.correctOption(h.Option) {
+				return fieldError(fmt.Sprintf("hints[%d].option", i), "can't be a correct option")
+			}
+			if slices.Contains(eliminated, h.Option) {
+				return fieldError(fmt.Sprintf("hints[%d].option", i), "is already eliminated")
+			}
+			eliminated = append(eliminated, h.Option)
+		default:
+			return fieldError(fmt.Sprintf("hints[%d].type", i), "%q is not a known hint type", h.Type)
+		}
+	}
+	// eliminated options are distinct wrong ones, so counting them is enough
+	if len(eliminated) > 0 && len(eliminated) >= len(q.Options)-q.correctOptionCount() {
+		return fieldError("hints", "can't eliminate every wrong option")
+	}
+	return nil
+}
+
+// correctOption reports whether option o is part of the answer key of a choice question.
+func (q *Question) correctOption(o int) bool {
+	if q.Kind() == QuestionMultiSelect {
+		return slices.Contains(q.Answers, o)
+	}
+	return o == q.Answer
+}
+
+func (q *Question) correctOptionCount() int {
+	if q.Kind() == QuestionMultiSelect {
+		return len(q.Answers)
+	}
+	return 1
+}
@@ -0,0 +1,595 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuestionType selects how a question is validated and graded.
+type QuestionType string
+
+const (
+	QuestionSingleChoice QuestionType = "single_choice"
+	QuestionMultiSelect  QuestionType = "multi_select"
+	QuestionTrueFalse    QuestionType = "true_false"
+	QuestionNumeric      QuestionType = "numeric"
+	QuestionOrdering     QuestionType = "ordering"
+	QuestionShortText    QuestionType = "short_text"
+)
+
+// QuestionTypes lists every supported question type.
+var QuestionTypes = []QuestionType{
+	QuestionSingleChoice,
+	QuestionMultiSelect,
+	QuestionTrueFalse,
+	QuestionNumeric,
+	QuestionOrdering,
+	QuestionShortText,
+}
+
+type Question struct {
+	ID      primitive.ObjectID `bson:"id,omitempty" json:"id"`
+	Type    QuestionType       `bson:"type,omitempty" json:"type,omitempty"` // empty means single_choice
+	Text    string             `bson:"text" json:"text" validate:"notblank"`
+	Options []string           `bson:"options" json:"options" validate:"distinct_options,dive,notblank"`
+
+	// single_choice and true_false: index of the correct option
+	Answer int `bson:"answer" json:"answer"`
+	// multi_select: indexes of every correct option
+	Answers []int `bson:"answers,omitempty" json:"answers,omitempty"`
+	// ordering: option indexes in the correct order
+	Order []int `bson:"order,omitempty" json:"order,omitempty"`
+	// numeric: expected value and accepted absolute difference
+	NumericAnswer float64 `bson:"numeric_answer,omitempty" json:"numeric_answer,omitempty"`
+	Tolerance     float64 `bson:"tolerance,omitempty" json:"tolerance,omitempty"`
+	// short_text: accepted answers, compared after normalization, and optionally with typos allowed
+	AcceptedAnswers []string `bson:"accepted_answers,omitempty" json:"accepted_answers,omitempty"`
+	Fuzzy           bool     `bson:"fuzzy,omitempty" json:"fuzzy,omitempty"`
+
+	// Relative weight used by the weighted scoring strategies, 0 means 1
+	Weight float64 `bson:"weight,omitempty" json:"weight,omitempty" validate:"gte=0"`
+
+	// Shown with the answer once the attempt is submitted, never before
+	Explanation string `bson:"explanation,omitempty" json:"explanation,omitempty"`
+	Reference   string `bson:"reference,omitempty" json:"reference,omitempty"` // link or citation backing the answer
+
+	// Revealed one at a time during an attempt, each one costing points
+	Hints []Hint `bson:"hints,omitempty" json:"hints,omitempty"`
+}
+
+// FieldError is a validation problem located on one of the fields of a quiz or
+// question, named by its JSON path. Questions name the path within the question.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+func fieldError(field, format string, args ...any) error {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// questionGrader validates and grades one question type.
+type questionGrader interface {
+	validate(q *Question) error
+	grade(q *Question, answer any) bool
+	correctAnswer(q *Question) any
+}
+
+var graders = map[QuestionType]questionGrader{
+	QuestionSingleChoice: singleChoiceGrader{},
+	QuestionMultiSelect:  multiSelectGrader{},
+	QuestionTrueFalse:    trueFalseGrader{},
+	QuestionNumeric:      numericGrader{},
+	QuestionOrdering:     orderingGrader{},
+	QuestionShortText:    shortTextGrader{},
+}
+
+// Kind returns the question type, defaulting to single_choice for legacy questions.
+func (q *Question) Kind() QuestionType {
+	if q.Type == "" {
+		return QuestionSingleChoice
+	}
+	return q.Type
+}
+
+// Validate checks the question is well formed for its type. Problems are
+// reported as a *FieldError.
+func (q *Question) Validate() error {
+	g, ok := graders[q.Kind()]
+	if !ok {
+		return fieldError("type", "%q is not a supported question type", q.Type)
+	}
+	if strings.TrimSpace(q.Text) == "" {
+		return fieldError("text", "is required")
+	}
+	if err := g.validate(q); err != nil {
+		return err
+	}
+	return q.validateHints()
+}
+
+// Grade reports whether the submitted answer is correct. The answer is the value
+// decoded from the client JSON: a number, a string, a boolean or a list.
+func (q *Question) Grade(answer any) bool {
+	g, ok := graders[q.Kind()]
+	if !ok || answer == nil {
+		return false
+	}
+	return g.grade(q, answer)
+}
+
+// partialGrader is implemented by question types that can award partial credit.
+type partialGrader interface {
+	credit(q *Question, answer any) float64
+}
+
+// Credit returns the share of the question earned by the answer, between 0 and 1.
+// Only types with a notion of partial answers return values in between.
+func (q *Question) Credit(answer any) float64 {
+	g, ok := graders[q.Kind()]
+	if !ok || answer == nil {
+		return 0
+	}
+	if pg, ok := g.(partialGrader); ok {
+		return pg.credit(q, answer)
+	}
+	if g.grade(q, answer) {
+		return 1
+	}
+	return 0
+}
+
+// QuestionWeight returns the weight of the question, defaulting to 1.
+func (q *Question) QuestionWeight() float64 {
+	if q.Weight <= 0 {
+		return 1
+	}
+	return q.Weight
+}
+
+// CorrectAnswer returns the answer key in the shape a client would submit it.
+func (q *Question) CorrectAnswer() any {
+	g, ok := graders[q.Kind()]
+	if !ok {
+		return nil
+	}
+	return g.correctAnswer(q)
+}
+
+// ShufflesOptions reports whether the options of the question can be shown in
+// another order. True/false questions keep their natural order.
+func (q *Question) ShufflesOptions() bool {
+	switch q.Kind() {
+	case QuestionSingleChoice, QuestionMultiSelect, QuestionOrdering:
+		return len(q.Options) > 1
+	}
+	return false
+}
+
+// Displayed returns the question with its options shown in order, where order[i]
+// is the original index of the i-th option, and the answer keys remapped to the
+// shown positions. A nil order returns the question unchanged.
+func (q Question) Displayed(order []int) Question {
+	if len(order) != len(q.Options) {
+		return q
+	}
+	shown := make([]int, len(order))
+	options := make([]string, len(order))
+	for i, o := range order {
+		shown[o] = i
+		options[i] = q.Options[o]
+	}
+	remap := func(indexes []int) []int {
+		if indexes == nil {
+			return nil
+		}
+		out := make([]int, len(indexes))
+		for i, idx := range indexes {
+			out[i] = idx
+			if idx >= 0 && idx < len(shown) {
+				out[i] = shown[idx]
+			}
+		}
+		return out
+	}
+
+	q.Options = options
+	if q.Answer >= 0 && q.Answer < len(shown) {
+		q.Answer = shown[q.Answer]
+	}
+	q.Answers = remap(q.Answers)
+	q.Order = remap(q.Order)
+	if q.Hints != nil {
+		hints := slices.Clone(q.Hints)
+		for i := range hints {
+			if hints[i].Type == HintEliminate && hints[i].Option >= 0 && hints[i].Option < len(shown) {
+				hints[i].Option = shown[hints[i].Option]
+			}
+		}
+		q.Hints = hints
+	}
+	return q
+}
+
+// CanonicalAnswer maps an answer given on options shown in order back to the
+// original option indexes, so it can be graded against the answer key.
+// Answers that aren't option indexes are returned unchanged.
+func (q *Question) CanonicalAnswer(answer any, order []int) any {
+	if len(order) != len(q.Options) || answer == nil {
+		return answer
+	}
+	original := func(i int) int {
+		if i >= 0 && i < len(order) {
+			return order[i]
+		}
+		return i
+	}
+	switch q.Kind() {
+	case QuestionSingleChoice, QuestionTrueFalse:
+		if _, isBool := answer.(bool); isBool {
+			return answer
+		}
+		if i, ok := toInt(answer); ok {
+			return original(i)
+		}
+	case QuestionMultiSelect, QuestionOrdering:
+		if indexes, ok := toIntSlice(answer); ok {
+			out := make([]int, len(indexes))
+			for i, idx := range indexes {
+				out[i] = original(idx)
+			}
+			return out
+		}
+	}
+	return answer
+}
+
+type singleChoiceGrader struct{}
+
+func (singleChoiceGrader) validate(q *Question) error {
+	if err := validateOptions(q.Options, 2); err != nil {
+		return err
+	}
+	if q.Answer < 0 || q.Answer >= len(q.Options) {
+		return fieldError("answer", "out of range")
+	}
+	return nil
+}
+
+func (singleChoiceGrader) grade(q *Question, answer any) bool {
+	idx, ok := toInt(answer)
+	return ok && idx == q.Answer
+}
+
+func (singleChoiceGrader) correctAnswer(q *Question) any { return q.Answer }
+
+type multiSelectGrader struct{}
+
+func (multiSelectGrader) validate(q *Question) error {
+	if err := validateOptions(q.Options, 2); err != nil {
+		return err
+	}
+	if len(q.Answers) == 0 {
+		return fieldError("answers", "is required")
+	}
+	seen := make(map[int]bool, len(q.Answers))
+	for _, a := range q.Answers {
+		if a < 0 || a >= len(q.Options) {
+			return fieldError("answers", "out of range")
+		}
+		if seen[a] {
+			return fieldError("answers", "must be unique")
+		}
+		seen[a] = true
+	}
+	return nil
+}
+
+func (multiSelectGrader) grade(q *Question, answer any) bool {
+	selected, ok := toIntSlice(answer)
+	if !ok || len(selected) != len(q.Answers) {
+		return false
+	}
+	want := make(map[int]bool, len(q.Answers))
+	for _, a := range q.Answers {
+		want[a] = true
+	}
+	for _, s := range selected {
+		if !want[s] {
+			return false
+		}
+		delete(want, s)
+	}
+	return len(want) == 0
+}
+
+// credit gives one share per correct option selected and takes one back per wrong option.
+func (multiSelectGrader) credit(q *Question, answer any) float64 {
+	selected, ok := toIntSlice(answer)
+	if !ok {
+		return 0
+	}
+	want := make(map[int]bool, len(q.Answers))
+	for _, a := range q.Answers {
+		want[a] = true
+	}
+	hits, misses := 0, 0
+	seen := make(map[int]bool, len(selected))
+	for _, s := range selected {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if want[s] {
+			hits++
+		} else {
+			misses++
+		}
+	}
+	return math.Max(0, float64(hits-misses)/float64(len(q.Answers)))
+}
+
+func (multiSelectGrader) correctAnswer(q *Question) any { return q.Answers }
+
+type trueFalseGrader struct{}
+
+func (trueFalseGrader) validate(q *Question) error {
+	if len(q.Options) != 2 {
+		return fieldError("options", "must be exactly 2 for true_false questions")
+	}
+	if q.Answer != 0 && q.Answer != 1 {
+		return fieldError("answer", "out of range")
+	}
+	return nil
+}
+
+func (trueFalseGrader) grade(q *Question, answer any) bool {
+	if b, ok := answer.(bool); ok {
+		answer = boolOption(q.Options, b)
+	}
+	idx, ok := toInt(answer)
+	return ok && idx == q.Answer
+}
+
+func (trueFalseGrader) correctAnswer(q *Question) any { return q.Answer }
+
+// boolOption maps true/false to the matching option index, assuming "True" comes first otherwise.
+func boolOption(options []string, b bool) int {
+	label := strconv.FormatBool(b)
+	for i, o := range options {
+		if strings.EqualFold(strings.TrimSpace(o), label) {
+			return i
+		}
+	}
+	if b {
+		return 0
+	}
+	return 1
+}
+
+type numericGrader struct{}
+
+func (numericGrader) validate(q *Question) error {
+	if q.Tolerance < 0 {
+		return fieldError("tolerance", "can't be negative")
+	}
+	if math.IsNaN(q.NumericAnswer) || math.IsInf(q.NumericAnswer, 0) {
+		return fieldError("numeric_answer", "must be a finite number")
+	}
+	return nil
+}
+
+func (numericGrader) grade(q *Question, answer any) bool {
+	v, ok := toFloat(answer)
+	return ok && math.Abs(v-q.NumericAnswer) <= q.Tolerance
+}
+
+func (numericGrader) correctAnswer(q *Question) any { return q.NumericAnswer }
+
+type orderingGrader struct{}
+
+func (orderingGrader) validate(q *Question) error {
+	if err := validateOptions(q.Options, 2); err != nil {
+		return err
+	}
+	if len(q.Order) != len(q.Options) {
+		return fieldError("order", "must list every option exactly once")
+	}
+	seen := make(map[int]bool, len(q.Order))
+	for _, o := range q.Order {
+		if o < 0 || o >= len(q.Options) || seen[o] {
+			return fieldError("order", "must list every option exactly once")
+		}
+		seen[o] = true
+	}
+	return nil
+}
+
+func (orderingGrader) grade(q *Question, answer any) bool {
+	order, ok := toIntSlice(answer)
+	if !ok || len(order) != len(q.Order) {
+		return false
+	}
+	for i := range order {
+		if order[i] != q.Order[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (orderingGrader) correctAnswer(q *Question) any { return q.Order }
+
+type shortTextGrader struct{}
+
+func (shortTextGrader) validate(q *Question) error {
+	if len(q.AcceptedAnswers) == 0 {
+		return fieldError("accepted_answers", "is required")
+	}
+	for _, a := range q.AcceptedAnswers {
+		if normalizeText(a) == "" {
+			return fieldError("accepted_answers", "can't contain blank answers")
+		}
+	}
+	return nil
+}
+
+func (shortTextGrader) grade(q *Question, answer any) bool {
+	text, ok := answer.(string)
+	if !ok {
+		return false
+	}
+	given := normalizeText(text)
+	if given == "" {
+		return false
+	}
+	for _, accepted := range q.AcceptedAnswers {
+		want := normalizeText(accepted)
+		if given == want {
+			return true
+		}
+		if q.Fuzzy && levenshtein(given, want) <= fuzzyDistance(want) {
+			return true
+		}
+	}
+	return false
+}
+
+func (shortTextGrader) correctAnswer(q *Question) any { return q.AcceptedAnswers }
+
+func validateOptions(options []string, minOptions int) error {
+	if len(options) < minOptions {
+		return fieldError("options", "must have at least %d entries", minOptions)
+	}
+	for _, o := range options {
+		if strings.TrimSpace(o) == "" {
+			return fieldError("options", "can't be blank")
+		}
+	}
+	return nil
+}
+
+// normalizeText lowercases, drops punctuation and collapses whitespace.
+func normalizeText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// minFuzzyLength is the shortest expected answer that tolerates typos. Below it
+// a single edit turns most answers into another word ("4" into "5", "yes"
+// into "yet").
+const minFuzzyLength = 5
+
+// fuzzyDistance is the number of typos tolerated for an expected answer.
+func fuzzyDistance(want string) int {
+	n := len([]rune(want))
+	if n < minFuzzyLength {
+		return 0
+	}
+	return max(1, n/5)
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// toInt accepts JSON numbers and numeric strings, as older clients send answers as strings.
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, false
+		}
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toIntSlice accepts a JSON list of indexes or a comma separated string.
+func toIntSlice(v any) ([]int, bool) {
+	var items []any
+	switch list := v.(type) {
+	case []int:
+		return list, true
+	case []any:
+		items = list
+	case primitive.A:
+		items = list
+	case string:
+		for _, part := range strings.Split(list, ",") {
+			items = append(items, part)
+		}
+	default:
+		return nil, false
+	}
+	out := make([]int, 0, len(items))
+	for _, item := range items {
+		i, ok := toInt(item)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, i)
+	}
+	return out, true
+}
@@ -0,0 +1,112 @@
+package model
+
+import "testing"
+
+func TestGrade(t *testing.T) {
+	single := Question{Options: []string{"a", "b", "c"}, Answer: 1}
+	multi := Question{Type: QuestionMultiSelect, Options: []string{"a", "b", "c", "d"}, Answers: []int{0, 2}}
+	trueFalse := Question{Type: QuestionTrueFalse, Options: []string{"True", "False"}, Answer: 1}
+	numeric := Question{Type: QuestionNumeric, NumericAnswer: 3.14, Tolerance: 0.01}
+	exact := Question{Type: QuestionNumeric, NumericAnswer: 42}
+	ordering := Question{Type: QuestionOrdering, Options: []string{"a", "b", "c"}, Order: []int{2, 0, 1}}
+	text := Question{Type: QuestionShortText, AcceptedAnswers: []string{"Paris"}}
+	fuzzy := Question{Type: QuestionShortText, AcceptedAnswers: []string{"Mitochondria", "4", "yes"}, Fuzzy: true}
+
+	tests := []struct {
+		name   string
+		q      Question
+		answer any
+		want   bool
+	}{
+		{"single choice right", single, 1, true},
+		{"single choice from json", single, 1.0, true},
+		{"single choice wrong", single, 0, false},
+		{"single choice fraction", single, 1.5, false},
+		{"single choice missing", single, nil, false},
+		{"multi select right in any order", multi, []any{2.0, 0.0}, true},
+		{"multi select as text", multi, "0,2", true},
+		{"multi select missing one", multi, []any{0.0}, false},
+		{"multi select one too many", multi, []any{0.0, 1.0, 2.0}, false},
+		{"multi select repeated", multi, []any{0.0, 0.0}, false},
+		{"true false by index", trueFalse, 1, true},
+		{"true false by bool", trueFalse, false, true},
+		{"true false wrong bool", trueFalse, true, false},
+		{"numeric within tolerance", numeric, 3.149, true},
+		{"numeric on the tolerance", numeric, "3.15", true},
+		{"numeric out of tolerance", numeric, 3.2, false},
+		{"numeric exact", exact, 42, true},
+		{"numeric exact missed", exact, 42.0001, false},
+		{"numeric not a number", numeric, "pi", false},
+		{"ordering right", ordering, []any{2.0, 0.0, 1.0}, true},
+		{"ordering swapped", ordering, []any{0.0, 2.0, 1.0}, false},
+		{"ordering short", ordering, []any{2.0, 0.0}, false},
+		{"short text normalized", text, "  paris! ", true},
+		{"short text typo without fuzzy", text, "Pari", false},
+		{"short text blank", text, " ", false},
+		{"fuzzy typo on a long answer", fuzzy, "mitocondria", true},
+		{"fuzzy too many typos", fuzzy, "mitocndra", false},
+		{"fuzzy short answers are exact", fuzzy, "5", false},
+		{"fuzzy short word", fuzzy, "yet", false},
+		{"fuzzy short answer exact", fuzzy, "YES", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Grade(tt.answer); got != tt.want {
+				t.Errorf("Grade(%v) = %v, want %v", tt.answer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredit(t *testing.T) {
+	multi := Question{Type: QuestionMultiSelect, Options: []string{"a", "b", "c", "d"}, Answers: []int{0, 1, 2}}
+	ordering := Question{Type: QuestionOrdering, Options: []string{"a", "b", "c"}, Order: []int{2, 0, 1}}
+	numeric := Question{Type: QuestionNumeric, NumericAnswer: 10, Tolerance: 0.5}
+
+	tests := []struct {
+		name   string
+		q      Question
+		answer any
+		want   float64
+	}{
+		{"multi select all", multi, []any{0.0, 1.0, 2.0}, 1},
+		{"multi select some", multi, []any{0.0, 1.0}, 2.0 / 3},
+		{"multi select wrong option takes one back", multi, []any{0.0, 1.0, 3.0}, 1.0 / 3},
+		{"multi select never negative", multi, []any{3.0}, 0},
+		{"multi select repeats count once", multi, []any{0.0, 0.0}, 1.0 / 3},
+		{"multi select not a list", multi, true, 0},
+		{"ordering has no partial credit", ordering, []any{2.0, 1.0, 0.0}, 0},
+		{"ordering right", ordering, []any{2.0, 0.0, 1.0}, 1},
+		{"numeric within tolerance", numeric, 10.5, 1},
+		{"numeric out of tolerance", numeric, 10.6, 0},
+		{"no answer", multi, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Credit(tt.answer); got != tt.want {
+				t.Errorf("Credit(%v) = %v, want %v", tt.answer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyDistance(t *testing.T) {
+	tests := []struct {
+		want  string
+		typos int
+	}{
+		{"4", 0},
+		{"go", 0},
+		{"yes", 0},
+		{"four", 0},
+		{"Paris", 1},
+		{"elephants", 1},
+		{"mitochondria", 2},
+		{"éléphant", 1}, // counted in runes
+	}
+	for _, tt := range tests {
+		if got := fuzzyDistance(tt.want); got != tt.typos {
+			t.Errorf("fuzzyDistance(%q) = %d, want %d", tt.want, got, tt.typos)
+		}
+	}
+}
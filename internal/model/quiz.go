@@ -6,26 +6,357 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type Question struct {
-	ID      primitive.ObjectID `bson:"id,omitempty" json:"id"`
-	Text    string             `bson:"text" json:"text"`
-	Options []string           `bson:"options" json:"options"`
-	Answer  int                `bson:"answer" json:"answer"`
+// RetakeMode controls how many times a user may submit a quiz.
+type RetakeMode string
+
+const (
+	RetakeSingle    RetakeMode = "single"
+	RetakeUnlimited RetakeMode = "unlimited"
+	RetakeLimited   RetakeMode = "limited"
+)
+
+// CountedAttempt selects which attempt counts toward the user's score and average.
+type CountedAttempt string
+
+const (
+	CountFirst  CountedAttempt = "first"
+	CountBest   CountedAttempt = "best"
+	CountLatest CountedAttempt = "latest"
+)
+
+// RetakePolicy is the per-quiz retake configuration. The zero value is a
+// single attempt where the first one counts.
+type RetakePolicy struct {
+	Mode        RetakeMode     `bson:"mode,omitempty" json:"mode,omitempty"`
+	MaxAttempts int            `bson:"max_attempts,omitempty" json:"max_attempts,omitempty"` // only for limited
+	Cooldown    int            `bson:"cooldown,omitempty" json:"cooldown,omitempty"`         // seconds between attempts
+	Counted     CountedAttempt `bson:"counted,omitempty" json:"counted,omitempty"`
+}
+
+// AttemptLimit returns the maximum number of submissions, 0 meaning unlimited.
+func (p RetakePolicy) AttemptLimit() int {
+	switch p.Mode {
+	case RetakeUnlimited:
+		return 0
+	case RetakeLimited:
+		return p.MaxAttempts
+	default:
+		return 1
+	}
+}
+
+// CountedRule returns the counted attempt rule, defaulting to the first attempt.
+func (p RetakePolicy) CountedRule() CountedAttempt {
+	if p.Counted == "" {
+		return CountFirst
+	}
+	return p.Counted
+}
+
+// ScoringStrategy selects how graded questions are turned into points.
+type ScoringStrategy string
+
+const (
+	ScoreProportional   ScoringStrategy = "proportional"
+	ScoreWeighted       ScoringStrategy = "weighted"
+	ScoreNegativeMarked ScoringStrategy = "negative_marking"
+)
+
+// ScoringConfig is the per-quiz scoring setup. The zero value splits Points
+// evenly across questions with all-or-nothing credit. Unset penalties take their
+// default, while an explicit 0 turns them off.
+type ScoringConfig struct {
+	Strategy      ScoringStrategy `bson:"strategy,omitempty" json:"strategy,omitempty"`
+	PartialCredit bool            `bson:"partial_credit,omitempty" json:"partial_credit,omitempty"` // multi_select questions
+	Penalty       *float64        `bson:"penalty,omitempty" json:"penalty,omitempty"`               // negative_marking: share of a question's value lost when wrong
+	SpeedBonus    int             `bson:"speed_bonus,omitempty" json:"speed_bonus,omitempty"`       // max extra points for finishing a timed quiz early
+	HintPenalty   *float64        `bson:"hint_penalty,omitempty" json:"hint_penalty,omitempty"`     // share of a question's value lost per hint revealed
+}
+
+// QuizStatus is the publication state of a quiz. Only published quizzes are
+// listed in the catalog and can be played.
+type QuizStatus string
+
+const (
+	QuizDraft     QuizStatus = "draft"
+	QuizInReview  QuizStatus = "in_review"
+	QuizScheduled QuizStatus = "scheduled"
+	QuizPublished QuizStatus = "published"
+	QuizArchived  QuizStatus = "archived"
+)
+
+// AccessTier is the subscription plan needed to play a quiz.
+type AccessTier string
+
+const (
+	TierFree       AccessTier = "free"
+	TierPro        AccessTier = "pro"
+	TierEnterprise AccessTier = "enterprise"
+)
+
+var tierRank = map[AccessTier]int{"": 0, TierFree: 0, TierPro: 1, TierEnterprise: 2}
+
+// Valid reports whether t is a known tier. The empty tier is free.
+func (t AccessTier) Valid() bool {
+	_, ok := tierRank[t]
+	return ok
+}
+
+// Includes reports whether a user on tier t may play quizzes requiring required.
+func (t AccessTier) Includes(required AccessTier) bool {
+	return tierRank[t] >= tierRank[required]
+}
+
+// Visibility controls who can find a quiz. Unlisted and private quizzes stay out
+// of the catalog and are opened through share links.
+type Visibility string
+
+const (
+	VisibilityPublic   Visibility = "public"
+	VisibilityUnlisted Visibility = "unlisted" // anyone holding a share link
+	VisibilityPrivate  Visibility = "private"  // signed-in users holding a share link
+)
+
+// Valid reports whether v is a known visibility. The empty visibility is public.
+func (v Visibility) Valid() bool {
+	switch v {
+	case "", VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return true
+	}
+	return false
 }
 
 type Quiz struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title       string             `bson:"title" json:"title"`
+	Title       string             `bson:"title" json:"title" validate:"notblank"`
 	Category    string             `bson:"category" json:"category"`
 	Description string             `bson:"description,omitempty" json:"description,omitempty"`
 	Difficulty  string             `bson:"difficulty,omitempty" json:"difficulty,omitempty"`
-	Questions   []Question         `bson:"questions" json:"questions"`
-	Points      int                `bson:"points" json:"points"`
+	Tags        []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Questions   []Question         `bson:"questions" json:"questions" validate:"dive"`
+	Rules       []QuestionRule     `bson:"rules,omitempty" json:"rules,omitempty"` // bank questions drawn for each attempt, after Questions
+	Points      int                `bson:"points" json:"points" validate:"gt=0"`
+	TimeLimit   int                `bson:"time_limit,omitempty" json:"time_limit,omitempty" validate:"gte=0"` // seconds, 0 means untimed
+	Retake      RetakePolicy       `bson:"retake,omitempty" json:"retake"`
+	Scoring     ScoringConfig      `bson:"scoring,omitempty" json:"scoring"`
+	KeepOrder   bool               `bson:"keep_order,omitempty" json:"keep_order,omitempty"` // don't shuffle questions and options per attempt
+	AccessTier  AccessTier         `bson:"access_tier,omitempty" json:"access_tier,omitempty"`
+	Visibility  Visibility         `bson:"visibility,omitempty" json:"visibility,omitempty"`
 	QuizID      primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`
 	Attempted   bool               `bson:"-" json:"attempted"`
-	// UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
-	CreatedAt time.Time `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	Locked      bool               `bson:"-" json:"locked"` // the viewer's plan doesn't include the quiz
+	// Catalog counters, maintained by the server
+	AttemptCount  int     `bson:"attempt_count" json:"attempt_count"`
+	AverageRating float64 `bson:"average_rating" json:"average_rating"`
+	RatingCount   int     `bson:"rating_count" json:"rating_count"`
+	// Only filled by the summary projection
+	QuestionCount int                `bson:"question_count,omitempty" json:"question_count,omitempty"`
+	UserID        primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"` // creator, zero for quizzes created before ownership
+	Status        QuizStatus         `bson:"status,omitempty" json:"status"`
+	PublishAt     *time.Time         `bson:"publish_at,omitempty" json:"publish_at,omitempty"` // requested publication time
+	PublishedAt   *time.Time         `bson:"published_at,omitempty" json:"published_at,omitempty"`
+	Version       int                `bson:"version" json:"version"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+	DeletedAt     *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// QuizStatus returns the quiz status. Quizzes created before the publication
+// workflow are published.
+func (q *Quiz) QuizStatus() QuizStatus {
+	if q.Status == "" {
+		return QuizPublished
+	}
+	return q.Status
+}
+
+// HasRules reports whether attempts draw questions from the question bank.
+func (q *Quiz) HasRules() bool {
+	return len(q.Rules) > 0
+}
+
+// Tier returns the plan needed to play the quiz, free by default.
+func (q *Quiz) Tier() AccessTier {
+	if q.AccessTier == "" {
+		return TierFree
+	}
+	return q.AccessTier
 }
 
-// user_id if we creating a admin panel for the system then it will help
+// QuizVisibility returns the quiz visibility, public by default.
+func (q *Quiz) QuizVisibility() Visibility {
+	if q.Visibility == "" {
+		return VisibilityPublic
+	}
+	return q.Visibility
+}
+
+// Listed reports whether the quiz may appear in the catalog, search and
+// notifications once published.
+func (q *Quiz) Listed() bool {
+	return q.QuizVisibility() == VisibilityPublic
+}
+
+// IsPublic reports whether the quiz is visible in the catalog and playable.
+func (q *Quiz) IsPublic() bool {
+	return q.QuizStatus() == QuizPublished
+}
+
+// OwnedBy reports whether the quiz was created by the given user.
+func (q *Quiz) OwnedBy(userID primitive.ObjectID) bool {
+	return !q.UserID.IsZero() && q.UserID == userID
+}
+
+// QuizVersion is an immutable snapshot of a quiz's content, taken every time it
+// changes, so attempts are graded against the version that was played.
+type QuizVersion struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	QuizID    primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`
+	Version   int                `bson:"version" json:"version"`
+	Quiz      Quiz               `bson:"quiz" json:"quiz"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// PublicQuestion is the player-facing view of a Question. It never carries the answer.
+type PublicQuestion struct {
+	ID      primitive.ObjectID `json:"id"`
+	Type    QuestionType       `json:"type"`
+	Text    string             `json:"text"`
+	Options []string           `json:"options,omitempty"`
+	// Hints revealed so far in the attempt, and how many the question has
+	Hints     []Hint `json:"hints,omitempty"`
+	HintCount int    `json:"hint_count,omitempty"`
+}
+
+// PublicQuiz is the player-facing view of a Quiz served by the catalog endpoints.
+type PublicQuiz struct {
+	ID            primitive.ObjectID `json:"id"`
+	Title         string             `json:"title"`
+	Category      string             `json:"category"`
+	Description   string             `json:"description,omitempty"`
+	Difficulty    string             `json:"difficulty,omitempty"`
+	Tags          []string           `json:"tags,omitempty"`
+	Questions     []PublicQuestion   `json:"questions"`
+	Rules         []QuestionRule     `json:"rules,omitempty"`
+	Points        int                `json:"points"`
+	TimeLimit     int                `json:"time_limit,omitempty"`
+	Retake        RetakePolicy       `json:"retake"`
+	Scoring       ScoringConfig      `json:"scoring"`
+	KeepOrder     bool               `json:"keep_order,omitempty"`
+	AccessTier    AccessTier         `json:"access_tier"`
+	Locked        bool               `json:"locked"`
+	Visibility    Visibility         `json:"visibility"`
+	QuizID        primitive.ObjectID `json:"quiz_id"`
+	Attempted     bool               `json:"attempted"`
+	AttemptCount  int                `json:"attempt_count"`
+	AverageRating float64            `json:"average_rating"`
+	RatingCount   int                `json:"rating_count"`
+	UserID        primitive.ObjectID `json:"user_id,omitempty"`
+	Status        QuizStatus         `json:"status"`
+	PublishAt     *time.Time         `json:"publish_at,omitempty"`
+	Version       int                `json:"version"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+func (q Question) Public() PublicQuestion {
+	return PublicQuestion{
+		ID:        q.ID,
+		Type:      q.Kind(),
+		Text:      q.Text,
+		Options:   q.Options,
+		HintCount: len(q.Hints),
+	}
+}
+
+// Public strips the answer keys from the quiz. Locked quizzes are listed
+// without their questions.
+func (q Quiz) Public() PublicQuiz {
+	questions := make([]PublicQuestion, 0, len(q.Questions))
+	for _, question := range q.Questions {
+		if q.Locked {
+			break
+		}
+		questions = append(questions, question.Public())
+	}
+	return PublicQuiz{
+		ID:            q.ID,
+		Title:         q.Title,
+		Category:      q.Category,
+		Description:   q.Description,
+		Difficulty:    q.Difficulty,
+		Tags:          q.Tags,
+		Questions:     questions,
+		Rules:         q.Rules,
+		Points:        q.Points,
+		TimeLimit:     q.TimeLimit,
+		Retake:        q.Retake,
+		Scoring:       q.Scoring,
+		KeepOrder:     q.KeepOrder,
+		AccessTier:    q.Tier(),
+		Locked:        q.Locked,
+		Visibility:    q.QuizVisibility(),
+		QuizID:        q.QuizID,
+		Attempted:     q.Attempted,
+		AttemptCount:  q.AttemptCount,
+		AverageRating: q.AverageRating,
+		RatingCount:   q.RatingCount,
+		UserID:        q.UserID,
+		Status:        q.QuizStatus(),
+		PublishAt:     q.PublishAt,
+		Version:       q.Version,
+		CreatedAt:     q.CreatedAt,
+		UpdatedAt:     q.UpdatedAt,
+	}
+}
+
+// QuizSummary is the lightweight catalog view of a quiz, without questions.
+type QuizSummary struct {
+	ID            primitive.ObjectID `json:"id"`
+	Title         string             `json:"title"`
+	Category      string             `json:"category"`
+	Description   string             `json:"description,omitempty"`
+	Difficulty    string             `json:"difficulty,omitempty"`
+	Tags          []string           `json:"tags,omitempty"`
+	QuestionCount int                `json:"question_count"`
+	Points        int                `json:"points"`
+	TimeLimit     int                `json:"time_limit,omitempty"`
+	AccessTier    AccessTier         `json:"access_tier"`
+	Locked        bool               `json:"locked"`
+	Attempted     bool               `json:"attempted"`
+	AttemptCount  int                `json:"attempt_count"`
+	AverageRating float64            `json:"average_rating"`
+	RatingCount   int                `json:"rating_count"`
+	UserID        primitive.ObjectID `json:"user_id,omitempty"`
+	Status        QuizStatus         `json:"status"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// Summary returns the catalog view of the quiz. QuestionCount comes from the
+// summary projection when questions weren't loaded.
+func (q Quiz) Summary() QuizSummary {
+	count := q.QuestionCount
+	if len(q.Questions) > 0 {
+		count = len(q.Questions)
+	}
+	return QuizSummary{
+		ID:            q.ID,
+		Title:         q.Title,
+		Category:      q.Category,
+		Description:   q.Description,
+		Difficulty:    q.Difficulty,
+		Tags:          q.Tags,
+		QuestionCount: count,
+		Points:        q.Points,
+		TimeLimit:     q.TimeLimit,
+		AccessTier:    q.Tier(),
+		Locked:        q.Locked,
+		Attempted:     q.Attempted,
+		AttemptCount:  q.AttemptCount,
+		AverageRating: q.AverageRating,
+		RatingCount:   q.RatingCount,
+		UserID:        q.UserID,
+		Status:        q.QuizStatus(),
+		CreatedAt:     q.CreatedAt,
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rating is a player's rating of a quiz they completed, with an optional short
+// review. There is one per user and quiz; rating again replaces it.
+type Rating struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	QuizID    primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	UserName  string             `bson:"user_name" json:"user_name"`
+	Stars     int                `bson:"stars" json:"stars" validate:"min=1,max=5"`
+	Review    string             `bson:"review,omitempty" json:"review,omitempty" validate:"max=500"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
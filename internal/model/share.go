@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShareLink grants access to an unlisted or private quiz. The link itself is a
+// signed token naming the ShareLink, so revoking the record kills every copy of it.
+type ShareLink struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	QuizID    primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	Label     string             `bson:"label,omitempty" json:"label,omitempty"`
+	ExpiresAt *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	Token     string             `bson:"-" json:"token,omitempty"` // only filled while the link is active
+}
+
+// Active reports whether the link still opens its quiz.
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
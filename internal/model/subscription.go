@@ -30,3 +30,19 @@ type Subscription struct {
 	Subscription_Starting_Date time.Time          `bson:"subscription_starting_date,omitempty" json:"subscription_starting_date"`
 	Subscription_Ending_Date   time.Time          `bson:"subscription_ending_date,omitempty" json:"subscription_ending_date"`
 }
+
+// IsActive reports whether the subscription is paid for and running.
+func (s *Subscription) IsActive() bool {
+	return s.Status == StatusActive && s.StripeSubscriptionID != ""
+}
+
+// Tier returns the quiz access tier the subscription's plan unlocks.
+func (s *Subscription) Tier() AccessTier {
+	switch s.Plan {
+	case PlanEnterprise:
+		return TierEnterprise
+	case PlanPro:
+		return TierPro
+	}
+	return TierFree
+}
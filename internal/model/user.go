@@ -6,19 +6,55 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Role decides what a user is allowed to do besides playing.
+type Role string
+
+const (
+	RolePlayer    Role = "player"
+	RoleCreator   Role = "creator"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var Roles = []Role{RolePlayer, RoleCreator, RoleModerator, RoleAdmin}
+
 type User struct {
 	ID               primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
 	Email            string               `bson:"email" json:"email"`
 	Name             string               `bson:"name" json:"name"`
 	Score            int                  `bson:"score" json:"score"`
 	Password         string               `bson:"password" json:"-"`
+	Role             Role                 `bson:"role,omitempty" json:"role"`
 	RefreshToken     string               `bson:"refresh_token,omitempty" json:"-"`
 	AverageScore     float64              `bson:"average_score" json:"average_score"`
 	Rank             int                  `bson:"rank" json:"rank"`
 	Streak           int                  `bson:"streak" json:"streak"`
 	Activity         map[string]int       `bson:"activity" json:"activity"`
 	CompletedQuizIDs []primitive.ObjectID `bson:"completed_quiz_ids" json:"completed_quiz_ids"`
+	QuizStats        map[string]QuizStat  `bson:"quiz_stats,omitempty" json:"quiz_stats,omitempty"` // keyed by quiz id hex
+	StatsVersion     int                  `bson:"stats_version" json:"-"`                           // bumped by every stats update
 	UserId           primitive.ObjectID   `bson:"user_id" json:"user_id"`
 	CreatedAt        time.Time            `bson:"created_at" json:"created_at"`
 	UpdatedAt        time.Time            `bson:"updated_at" json:"updated_at"`
 }
+
+// UserRole returns the user's role. Users created before roles existed are players.
+func (u *User) UserRole() Role {
+	if u.Role == "" {
+		return RolePlayer
+	}
+	return u.Role
+}
+
+// QuizStat tracks a user's submissions of one quiz and the values of the attempt
+// that currently counts toward Score and AverageScore.
+type QuizStat struct {
+	Attempts      int       `bson:"attempts" json:"attempts"`
+	Points        int       `bson:"points" json:"points"`
+	Percentage    int       `bson:"percentage" json:"percentage"`
+	LastAttemptAt time.Time `bson:"last_attempt_at" json:"last_attempt_at"`
+	// The last attempt folded into the stats and the one whose points count. Older
+	// attempts are in the attempts collection.
+	LastAttemptID    primitive.ObjectID `bson:"last_attempt_id,omitempty" json:"last_attempt_id,omitempty"`
+	CountedAttemptID primitive.ObjectID `bson:"counted_attempt_id,omitempty" json:"counted_attempt_id,omitempty"`
+}
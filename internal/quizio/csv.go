@@ -0,0 +1,205 @@
+package quizio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+// CSV files have a header row and one question per row. Lists (options and
+// answers) are separated by "|", with "\|" standing for a "|" and "\\" for a
+// backslash inside an item, and answers are given as option text. Hints are a
+// list too, in the order they are revealed: "eliminate:" followed by the text of
+// the option it rules out, or "clue:" followed by the clue.
+//
+//	type,text,options,answer,tolerance,fuzzy,weight,explanation,reference,hints
+//	single_choice,Capital of France?,Paris|Lyon|Nice,Paris,,,,Paris has been the capital since 987.,,eliminate:Nice|clue:On the Seine
+//	multi_select,Pick the primes,2|4|5,2|5,,,,,,
+//	true_false,Go has generics,,true,,,,Since Go 1.18.,https://go.dev/doc/go1.18,
+//	numeric,Value of pi?,,3.14,0.01,,,,,
+//	ordering,Sort ascending,3|1|2,1|2|3,,,,,,
+//	short_text,Go's mascot?,,gopher|go gopher,,true,,,,clue:It's a rodent
+//
+// The explanation, reference and hints columns are optional.
+var csvHeader = []string{"type", "text", "options", "answer", "tolerance", "fuzzy", "weight", "explanation", "reference", "hints"}
+
+const (
+	csvEliminateHint = "eliminate:"
+	csvClueHint      = "clue:"
+)
+
+func decodeCSV(r io.Reader) (*Import, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "question" {
+			name = "text"
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["text"]; !ok {
+		return nil, errors.New("invalid csv header: a text column is required")
+	}
+
+	imp := &Import{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		q, err := csvQuestion(get)
+		imp.Rows = append(imp.Rows, Row{Line: line, Question: q, Err: err})
+	}
+	return imp, nil
+}
+
+func csvQuestion(get func(string) string) (model.Question, error) {
+	q := model.Question{
+		Type:        model.QuestionType(get("type")),
+		Text:        get("text"),
+		Options:     splitList(get("options")),
+		Explanation: get("explanation"),
+		Reference:   get("reference"),
+	}
+	if q.Type == "" {
+		q.Type = model.QuestionSingleChoice
+	}
+	if weight := get("weight"); weight != "" {
+		w, err := strconv.ParseFloat(weight, 64)
+		if err != nil {
+			return q, fmt.Errorf("invalid weight %q", weight)
+		}
+		q.Weight = w
+	}
+
+	for _, item := range splitList(get("hints")) {
+		if option, ok := strings.CutPrefix(item, csvEliminateHint); ok {
+			i := optionIndex(q.Options, option)
+			if i < 0 {
+				return q, fmt.Errorf("hint option %q is not one of the options", strings.TrimSpace(option))
+			}
+			q.Hints = append(q.Hints, model.Hint{Type: model.HintEliminate, Option: i})
+			continue
+		}
+		q.Hints = append(q.Hints, model.Hint{Type: model.HintClue, Text: strings.TrimSpace(strings.TrimPrefix(item, csvClueHint))})
+	}
+
+	answer := get("answer")
+	var err error
+	switch q.Type {
+	case model.QuestionSingleChoice:
+		if q.Answer = optionIndex(q.Options, answer); q.Answer < 0 {
+			err = fmt.Errorf("answer %q is not one of the options", answer)
+		}
+	case model.QuestionTrueFalse:
+		if len(q.Options) == 0 {
+			q.Options = trueFalseOptions
+		}
+		q.Answer, err = trueFalseAnswer(q.Options, answer)
+	case model.QuestionMultiSelect:
+		q.Answers, err = optionIndexes(q.Options, splitList(answer))
+	case model.QuestionOrdering:
+		// without an answer the options are listed in the correct order
+		if answer == "" {
+			for i := range q.Options {
+				q.Order = append(q.Order, i)
+			}
+		} else {
+			q.Order, err = optionIndexes(q.Options, splitList(answer))
+		}
+	case model.QuestionNumeric:
+		if q.NumericAnswer, err = strconv.ParseFloat(answer, 64); err != nil {
+			return q, fmt.Errorf("answer %q is not a number", answer)
+		}
+		if tolerance := get("tolerance"); tolerance != "" {
+			if q.Tolerance, err = strconv.ParseFloat(tolerance, 64); err != nil {
+				return q, fmt.Errorf("tolerance %q is not a number", tolerance)
+			}
+		}
+	case model.QuestionShortText:
+		q.AcceptedAnswers = splitList(answer)
+		if fuzzy := get("fuzzy"); fuzzy != "" {
+			if q.Fuzzy, err = strconv.ParseBool(fuzzy); err != nil {
+				return q, fmt.Errorf("fuzzy %q is not true or false", fuzzy)
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown question type %q", q.Type)
+	}
+	return q, err
+}
+
+func encodeCSV(w io.Writer, quiz *model.Quiz) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, q := range quiz.Questions {
+		record := []string{string(q.Kind()), q.Text, joinList(q.Options), "", "", "", "", q.Explanation, q.Reference, csvHints(&q)}
+		switch q.Kind() {
+		case model.QuestionSingleChoice, model.QuestionTrueFalse:
+			record[3] = joinList(pick(q.Options, []int{q.Answer}))
+		case model.QuestionMultiSelect:
+			record[3] = joinList(pick(q.Options, q.Answers))
+		case model.QuestionOrdering:
+			record[3] = joinList(pick(q.Options, q.Order))
+		case model.QuestionNumeric:
+			record[3] = formatFloat(q.NumericAnswer)
+			if q.Tolerance != 0 {
+				record[4] = formatFloat(q.Tolerance)
+			}
+		case model.QuestionShortText:
+			record[3] = joinList(q.AcceptedAnswers)
+			if q.Fuzzy {
+				record[5] = "true"
+			}
+		}
+		if q.Weight != 0 {
+			record[6] = formatFloat(q.Weight)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvHints(q *model.Question) string {
+	items := make([]string, 0, len(q.Hints))
+	for _, h := range q.Hints {
+		if h.Type == model.HintEliminate {
+			items = append(items, csvEliminateHint+strings.Join(pick(q.Options, []int{h.Option}), ""))
+		} else {
+			items = append(items, csvClueHint+h.Text)
+		}
+	}
+	return joinList(items)
+}
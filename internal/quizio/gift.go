@@ -0,0 +1,415 @@
+package quizio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+// GIFT is Moodle's plain text format. Questions are separated by blank lines:
+//
+//	::Q1:: Capital of France? {=Paris ~Lyon ~Nice}
+//	Pick the primes {~%50%2 ~%-100%4 ~%50%5}
+//	Go has generics {T}
+//	Value of pi? {#3.14:0.01}
+//	Go's mascot? {=gopher =go gopher}
+//
+// Matching, essay and description questions have no equivalent and are
+// reported as errors. Ordering questions and hints can't be written as GIFT.
+
+const giftSpecial = `~=#{}:\`
+
+func decodeGIFT(r io.Reader) (*Import, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	imp := &Import{}
+	var block []string
+	start, lineNo := 0, 0
+	flush := func() {
+		if len(block) > 0 {
+			q, err := giftQuestion(strings.Join(block, "\n"))
+			imp.Rows = append(imp.Rows, Row{Line: start, Question: q, Err: err})
+			block = nil
+		}
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			// answer blocks may span blank lines
+			if !giftOpenBrace(strings.Join(block, "\n")) {
+				flush()
+			}
+			continue
+		case strings.HasPrefix(trimmed, "//"):
+			continue
+		case len(block) == 0 && strings.HasPrefix(trimmed, "$CATEGORY:"):
+			category := strings.TrimSpace(strings.TrimPrefix(trimmed, "$CATEGORY:"))
+			if i := strings.LastIndex(category, "/"); i >= 0 {
+				category = category[i+1:]
+			}
+			imp.Quiz.Category = category
+			continue
+		}
+		if len(block) == 0 {
+			start = lineNo
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid gift: %w", err)
+	}
+	flush()
+	return imp, nil
+}
+
+// giftOpenBrace reports whether s has an answer block that isn't closed yet.
+func giftOpenBrace(s string) bool {
+	open := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			open = true
+		case '}':
+			open = false
+		}
+	}
+	return open
+}
+
+// giftIndex returns the index of the first unescaped c in s at or after from.
+func giftIndex(s string, c byte, from int) int {
+	for i := from; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+func giftUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func giftEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(giftSpecial, r) {
+			b.WriteByte('\\')
+		}
+		if r == '\n' {
+			b.WriteString(`\n`)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func giftQuestion(block string) (model.Question, error) {
+	var q model.Question
+
+	// ::title:: is optional and isn't kept
+	text := strings.TrimSpace(block)
+	if strings.HasPrefix(text, "::") {
+		if end := strings.Index(text[2:], "::"); end >= 0 {
+			text = text[end+4:]
+		}
+	}
+
+	open := giftIndex(text, '{', 0)
+	if open < 0 {
+		q.Text = giftUnescape(stripGIFTFormat(text))
+		return q, errors.New("description questions without an answer are not supported")
+	}
+	closing := giftIndex(text, '}', open)
+	if closing < 0 {
+		return q, errors.New("unclosed answer block")
+	}
+
+	q.Text = giftUnescape(stripGIFTFormat(text[:open]))
+	if after := giftUnescape(text[closing+1:]); after != "" {
+		q.Text += " _____ " + after
+	}
+
+	body := strings.TrimSpace(text[open+1 : closing])
+	// "####" starts the general feedback, kept as the explanation
+	if i := strings.LastIndex(body, "####"); i >= 0 && (i == 0 || body[i-1] != '\\') {
+		q.Explanation = giftUnescape(strings.TrimSpace(body[i+4:]))
+		body = strings.TrimSpace(body[:i])
+	}
+	switch {
+	case body == "":
+		return q, errors.New("essay questions are not supported")
+	case strings.HasPrefix(body, "#"):
+		return giftNumeric(q, body[1:])
+	}
+	switch strings.ToUpper(strings.TrimSpace(cutFeedback(body))) {
+	case "T", "TRUE":
+		q.Type, q.Options, q.Answer = model.QuestionTrueFalse, trueFalseOptions, 0
+		return q, nil
+	case "F", "FALSE":
+		q.Type, q.Options, q.Answer = model.QuestionTrueFalse, trueFalseOptions, 1
+		return q, nil
+	}
+	return giftChoices(q, body)
+}
+
+// stripGIFTFormat drops a leading [html], [plain], [markdown] or [moodle] marker.
+func stripGIFTFormat(s string) string {
+	s = strings.TrimSpace(s)
+	for _, marker := range []string{"[html]", "[plain]", "[markdown]", "[moodle]"} {
+		s = strings.TrimPrefix(s, marker)
+	}
+	return s
+}
+
+// cutFeedback removes the "#feedback" part of an answer.
+func cutFeedback(s string) string {
+	if i := giftIndex(s, '#', 0); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+type giftAnswer struct {
+	correct   bool // marked with =
+	weight    float64
+	hasWeight bool
+	text      string
+}
+
+func giftChoices(q model.Question, body string) (model.Question, error) {
+	if strings.Contains(body, "->") {
+		return q, errors.New("matching questions are not supported")
+	}
+
+	var answers []giftAnswer
+	start := -1
+	for i := 0; i <= len(body); i++ {
+		if i < len(body) && body[i] == '\\' {
+			i++
+			continue
+		}
+		if i == len(body) || body[i] == '=' || body[i] == '~' {
+			if start >= 0 {
+				a, err := parseGIFTAnswer(body[start], body[start+1:i])
+				if err != nil {
+					return q, err
+				}
+				answers = append(answers, a)
+			}
+			start = i
+		}
+	}
+	if len(answers) == 0 {
+		return q, errors.New("no answers found")
+	}
+
+	wrong, weighted := 0, false
+	for _, a := range answers {
+		if !a.correct {
+			wrong++
+		}
+		weighted = weighted || a.hasWeight
+	}
+
+	switch {
+	case wrong == 0:
+		q.Type = model.QuestionShortText
+		for _, a := range answers {
+			q.AcceptedAnswers = append(q.AcceptedAnswers, a.text)
+		}
+	case weighted:
+		q.Type = model.QuestionMultiSelect
+		for i, a := range answers {
+			q.Options = append(q.Options, a.text)
+			if a.correct || a.weight > 0 {
+				q.Answers = append(q.Answers, i)
+			}
+		}
+	default:
+		q.Type = model.QuestionSingleChoice
+		q.Answer = -1
+		for i, a := range answers {
+			q.Options = append(q.Options, a.text)
+			if a.correct {
+				if q.Answer >= 0 {
+					return q, errors.New("single choice questions have exactly one = answer")
+				}
+				q.Answer = i
+			}
+		}
+		if q.Answer < 0 {
+			return q, errors.New("no correct answer marked with =")
+		}
+	}
+	return q, nil
+}
+
+func parseGIFTAnswer(marker byte, s string) (giftAnswer, error) {
+	a := giftAnswer{correct: marker == '='}
+	s = strings.TrimSpace(cutFeedback(s))
+	if strings.HasPrefix(s, "%") {
+		end := strings.Index(s[1:], "%")
+		if end < 0 {
+			return a, fmt.Errorf("invalid answer weight in %q", s)
+		}
+		w, err := strconv.ParseFloat(s[1:end+1], 64)
+		if err != nil {
+			return a, fmt.Errorf("invalid answer weight in %q", s)
+		}
+		a.weight, a.hasWeight = w, true
+		s = s[end+2:]
+	}
+	a.text = giftUnescape(s)
+	if a.text == "" {
+		return a, errors.New("answers can't be blank")
+	}
+	return a, nil
+}
+
+// giftNumeric reads "value", "value:tolerance" or "min..max", optionally
+// prefixed with = as in the multiple answers form, of which the first is used.
+func giftNumeric(q model.Question, body string) (model.Question, error) {
+	q.Type = model.QuestionNumeric
+	body = strings.TrimSpace(body)
+	body = strings.TrimPrefix(body, "=")
+	if i := strings.IndexAny(body, "=~"); i >= 0 {
+		body = body[:i]
+	}
+	body = strings.TrimSpace(cutFeedback(body))
+	if strings.HasPrefix(body, "%") {
+		if end := strings.Index(body[1:], "%"); end >= 0 {
+			body = body[end+2:]
+		}
+	}
+
+	if min, max, ok := strings.Cut(body, ".."); ok {
+		lo, err1 := strconv.ParseFloat(strings.TrimSpace(min), 64)
+		hi, err2 := strconv.ParseFloat(strings.TrimSpace(max), 64)
+		if err1 != nil || err2 != nil {
+			return q, fmt.Errorf("invalid numeric range %q", body)
+		}
+		q.NumericAnswer, q.Tolerance = (lo+hi)/2, (hi-lo)/2
+		return q, nil
+	}
+
+	value, tolerance, _ := strings.Cut(body, ":")
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return q, fmt.Errorf("invalid numeric answer %q", body)
+	}
+	q.NumericAnswer = v
+	if tolerance != "" {
+		if q.Tolerance, err = strconv.ParseFloat(strings.TrimSpace(tolerance), 64); err != nil {
+			return q, fmt.Errorf("invalid tolerance %q", tolerance)
+		}
+	}
+	return q, nil
+}
+
+func encodeGIFT(w io.Writer, quiz *model.Quiz) error {
+	var b strings.Builder
+	if quiz.Title != "" {
+		fmt.Fprintf(&b, "// %s\n\n", strings.ReplaceAll(quiz.Title, "\n", " "))
+	}
+	if quiz.Category != "" {
+		fmt.Fprintf(&b, "$CATEGORY: $course$/%s\n\n", quiz.Category)
+	}
+
+	for i, q := range quiz.Questions {
+		if len(q.Hints) > 0 {
+			return fmt.Errorf("question %d: hints are %w", i+1, ErrUnsupported)
+		}
+		body, err := giftBody(&q)
+		if err != nil {
+			return fmt.Errorf("question %d: %w", i+1, err)
+		}
+		if q.Explanation != "" {
+			body += " ####" + giftEscape(q.Explanation)
+		}
+		fmt.Fprintf(&b, "::Q%d:: %s {%s}\n\n", i+1, giftEscape(q.Text), body)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func giftBody(q *model.Question) (string, error) {
+	var parts []string
+	switch q.Kind() {
+	case model.QuestionTrueFalse:
+		if q.Answer >= 0 && q.Answer < len(q.Options) {
+			switch strings.ToLower(q.Options[q.Answer]) {
+			case "true":
+				return "TRUE", nil
+			case "false":
+				return "FALSE", nil
+			}
+		}
+		// custom labels are written as a single choice question
+		fallthrough
+	case model.QuestionSingleChoice:
+		for i, o := range q.Options {
+			marker := "~"
+			if i == q.Answer {
+				marker = "="
+			}
+			parts = append(parts, marker+giftEscape(o))
+		}
+	case model.QuestionMultiSelect:
+		right := formatFloat(100 / float64(max(len(q.Answers), 1)))
+		wrong := formatFloat(-100 / float64(max(len(q.Options)-len(q.Answers), 1)))
+		for i, o := range q.Options {
+			weight := wrong
+			for _, a := range q.Answers {
+				if a == i {
+					weight = right
+				}
+			}
+			parts = append(parts, "~%"+weight+"%"+giftEscape(o))
+		}
+	case model.QuestionNumeric:
+		body := "#" + formatFloat(q.NumericAnswer)
+		if q.Tolerance != 0 {
+			body += ":" + formatFloat(q.Tolerance)
+		}
+		return body, nil
+	case model.QuestionShortText:
+		for _, a := range q.AcceptedAnswers {
+			parts = append(parts, "="+giftEscape(a))
+		}
+	default:
+		return "", fmt.Errorf("%s questions are %w", q.Kind(), ErrUnsupported)
+	}
+	return strings.Join(parts, " "), nil
+}
@@ -0,0 +1,71 @@
+package quizio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// jsonQuiz is the content of a quiz, without the fields managed by the server.
+type jsonQuiz struct {
+	Title       string              `json:"title"`
+	Category    string              `json:"category"`
+	Description string              `json:"description,omitempty"`
+	Difficulty  string              `json:"difficulty,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Points      int                 `json:"points"`
+	TimeLimit   int                 `json:"time_limit,omitempty"`
+	Retake      model.RetakePolicy  `json:"retake"`
+	Scoring     model.ScoringConfig `json:"scoring"`
+	Questions   []model.Question    `json:"questions"`
+}
+
+func decodeJSON(r io.Reader) (*Import, error) {
+	var q jsonQuiz
+	if err := json.NewDecoder(r).Decode(&q); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+
+	imp := &Import{Quiz: model.Quiz{
+		Title:       q.Title,
+		Category:    q.Category,
+		Description: q.Description,
+		Difficulty:  q.Difficulty,
+		Tags:        q.Tags,
+		Points:      q.Points,
+		TimeLimit:   q.TimeLimit,
+		Retake:      q.Retake,
+		Scoring:     q.Scoring,
+	}}
+	for i, question := range q.Questions {
+		question.ID = primitive.NilObjectID
+		imp.Rows = append(imp.Rows, Row{Line: i + 1, Question: question})
+	}
+	return imp, nil
+}
+
+func encodeJSON(w io.Writer, quiz *model.Quiz) error {
+	q := jsonQuiz{
+		Title:       quiz.Title,
+		Category:    quiz.Category,
+		Description: quiz.Description,
+		Difficulty:  quiz.Difficulty,
+		Tags:        quiz.Tags,
+		Points:      quiz.Points,
+		TimeLimit:   quiz.TimeLimit,
+		Retake:      quiz.Retake,
+		Scoring:     quiz.Scoring,
+		Questions:   make([]model.Question, len(quiz.Questions)),
+	}
+	for i, question := range quiz.Questions {
+		question.ID = primitive.NilObjectID
+		q.Questions[i] = question
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(q)
+}
@@ -0,0 +1,287 @@
+package quizio
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+// Moodle XML question types mapped to ours. Ordering questions use the
+// qtype_ordering plugin, which lists the answers in the correct order. Moodle
+// hints are text, so they carry clues; hints eliminating an option can't be
+// written.
+const (
+	moodleCategory    = "category"
+	moodleMultiChoice = "multichoice"
+	moodleTrueFalse   = "truefalse"
+	moodleNumerical   = "numerical"
+	moodleShortAnswer = "shortanswer"
+	moodleOrdering    = "ordering"
+)
+
+type moodleQuiz struct {
+	XMLName   xml.Name         `xml:"quiz"`
+	Questions []moodleQuestion `xml:"question"`
+}
+
+type moodleText struct {
+	Format string `xml:"format,attr,omitempty"`
+	Text   string `xml:"text"`
+}
+
+type moodleQuestion struct {
+	Type         string         `xml:"type,attr"`
+	Category     *moodleText    `xml:"category,omitempty"`
+	Name         *moodleText    `xml:"name,omitempty"`
+	QuestionText *moodleText    `xml:"questiontext,omitempty"`
+	DefaultGrade string         `xml:"defaultgrade,omitempty"`
+	Single       string         `xml:"single,omitempty"`
+	Feedback     *moodleText    `xml:"generalfeedback,omitempty"`
+	Answers      []moodleAnswer `xml:"answer"`
+	Hints        []moodleText   `xml:"hint"`
+}
+
+type moodleAnswer struct {
+	Fraction  string `xml:"fraction,attr"`
+	Format    string `xml:"format,attr,omitempty"`
+	Text      string `xml:"text"`
+	Tolerance string `xml:"tolerance,omitempty"`
+}
+
+func (a moodleAnswer) fraction() float64 {
+	f, _ := strconv.ParseFloat(a.Fraction, 64)
+	return f
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// plainText turns Moodle rich text into plain text.
+func (t *moodleText) plainText() string {
+	if t == nil {
+		return ""
+	}
+	if t.Format == "plain_text" || t.Format == "markdown" {
+		return strings.TrimSpace(t.Text)
+	}
+	return strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(t.Text, " ")))
+}
+
+func decodeMoodleXML(r io.Reader) (*Import, error) {
+	var doc moodleQuiz
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid moodle xml: %w", err)
+	}
+
+	imp := &Import{}
+	position := 0
+	for _, mq := range doc.Questions {
+		if mq.Type == moodleCategory {
+			category := mq.Category.plainText()
+			if i := strings.LastIndex(category, "/"); i >= 0 {
+				category = category[i+1:]
+			}
+			imp.Quiz.Category = category
+			continue
+		}
+		position++
+		q, err := moodleToQuestion(mq)
+		imp.Rows = append(imp.Rows, Row{Line: position, Question: q, Err: err})
+	}
+	return imp, nil
+}
+
+func moodleToQuestion(mq moodleQuestion) (model.Question, error) {
+	q := model.Question{Text: mq.QuestionText.plainText(), Explanation: mq.Feedback.plainText()}
+	if grade, err := strconv.ParseFloat(mq.DefaultGrade, 64); err == nil && grade > 0 && grade != 1 {
+		q.Weight = grade
+	}
+	for _, h := range mq.Hints {
+		if text := h.plainText(); text != "" {
+			q.Hints = append(q.Hints, model.Hint{Type: model.HintClue, Text: text})
+		}
+	}
+	answers := make([]string, len(mq.Answers))
+	for i, a := range mq.Answers {
+		answers[i] = (&moodleText{Format: a.Format, Text: a.Text}).plainText()
+	}
+
+	switch mq.Type {
+	case moodleMultiChoice:
+		q.Options = answers
+		if single, _ := strconv.ParseBool(mq.Single); single || mq.Single == "" {
+			q.Type = model.QuestionSingleChoice
+			best := 0.0
+			for i, a := range mq.Answers {
+				if f := a.fraction(); f > best {
+					best, q.Answer = f, i
+				}
+			}
+			if best == 0 {
+				return q, errors.New("no correct answer")
+			}
+		} else {
+			q.Type = model.QuestionMultiSelect
+			for i, a := range mq.Answers {
+				if a.fraction() > 0 {
+					q.Answers = append(q.Answers, i)
+				}
+			}
+		}
+	case moodleTrueFalse:
+		q.Type, q.Options = model.QuestionTrueFalse, trueFalseOptions
+		found := false
+		for i, a := range mq.Answers {
+			if a.fraction() > 0 {
+				answer, err := trueFalseAnswer(q.Options, answers[i])
+				if err != nil {
+					return q, err
+				}
+				q.Answer, found = answer, true
+			}
+		}
+		if !found {
+			return q, errors.New("no correct answer")
+		}
+	case moodleNumerical:
+		q.Type = model.QuestionNumeric
+		for i, a := range mq.Answers {
+			if a.fraction() <= 0 {
+				continue
+			}
+			v, err := strconv.ParseFloat(answers[i], 64)
+			if err != nil {
+				return q, fmt.Errorf("answer %q is not a number", answers[i])
+			}
+			q.NumericAnswer = v
+			if a.Tolerance != "" {
+				if q.Tolerance, err = strconv.ParseFloat(strings.TrimSpace(a.Tolerance), 64); err != nil {
+					return q, fmt.Errorf("tolerance %q is not a number", a.Tolerance)
+				}
+			}
+			return q, nil
+		}
+		return q, errors.New("no correct answer")
+	case moodleShortAnswer:
+		q.Type = model.QuestionShortText
+		for i, a := range mq.Answers {
+			if a.fraction() > 0 {
+				q.AcceptedAnswers = append(q.AcceptedAnswers, answers[i])
+			}
+		}
+	case moodleOrdering:
+		q.Type, q.Options = model.QuestionOrdering, answers
+		for i := range answers {
+			q.Order = append(q.Order, i)
+		}
+	default:
+		return q, fmt.Errorf("moodle %q questions are not supported", mq.Type)
+	}
+	return q, nil
+}
+
+func encodeMoodleXML(w io.Writer, quiz *model.Quiz) error {
+	doc := moodleQuiz{}
+	if quiz.Category != "" {
+		doc.Questions = append(doc.Questions, moodleQuestion{
+			Type:     moodleCategory,
+			Category: &moodleText{Text: "$course$/" + quiz.Category},
+		})
+	}
+
+	for i := range quiz.Questions {
+		q := &quiz.Questions[i]
+		mq := moodleQuestion{
+			Name:         &moodleText{Text: fmt.Sprintf("Q%d", i+1)},
+			QuestionText: &moodleText{Format: "plain_text", Text: q.Text},
+			DefaultGrade: formatFloat(q.QuestionWeight()),
+		}
+		if q.Explanation != "" {
+			mq.Feedback = &moodleText{Format: "plain_text", Text: q.Explanation}
+		}
+		for _, h := range q.Hints {
+			if h.Type != model.HintClue {
+				return fmt.Errorf("question %d: %s hints are %w", i+1, h.Type, ErrUnsupported)
+			}
+			mq.Hints = append(mq.Hints, moodleText{Format: "plain_text", Text: h.Text})
+		}
+		choices := func(correct func(int) float64) {
+			for j, o := range q.Options {
+				mq.Answers = append(mq.Answers, moodleAnswer{Fraction: formatFloat(correct(j)), Format: "plain_text", Text: o})
+			}
+		}
+
+		switch q.Kind() {
+		case model.QuestionSingleChoice:
+			mq.Type, mq.Single = moodleMultiChoice, "true"
+			choices(func(j int) float64 {
+				if j == q.Answer {
+					return 100
+				}
+				return 0
+			})
+		case model.QuestionMultiSelect:
+			mq.Type, mq.Single = moodleMultiChoice, "false"
+			right := 100 / float64(max(len(q.Answers), 1))
+			wrong := -100 / float64(max(len(q.Options)-len(q.Answers), 1))
+			choices(func(j int) float64 {
+				for _, a := range q.Answers {
+					if a == j {
+						return right
+					}
+				}
+				return wrong
+			})
+		case model.QuestionTrueFalse:
+			mq.Type = moodleTrueFalse
+			correct := q.Answer == 0
+			if q.Answer >= 0 && q.Answer < len(q.Options) && strings.EqualFold(q.Options[q.Answer], "false") {
+				correct = false
+			}
+			mq.Answers = []moodleAnswer{
+				{Fraction: fraction(correct), Text: "true"},
+				{Fraction: fraction(!correct), Text: "false"},
+			}
+		case model.QuestionNumeric:
+			mq.Type = moodleNumerical
+			mq.Answers = []moodleAnswer{{Fraction: "100", Text: formatFloat(q.NumericAnswer), Tolerance: formatFloat(q.Tolerance)}}
+		case model.QuestionShortText:
+			mq.Type = moodleShortAnswer
+			for _, a := range q.AcceptedAnswers {
+				mq.Answers = append(mq.Answers, moodleAnswer{Fraction: "100", Text: a})
+			}
+		case model.QuestionOrdering:
+			mq.Type = moodleOrdering
+			for _, o := range pick(q.Options, q.Order) {
+				mq.Answers = append(mq.Answers, moodleAnswer{Fraction: "0", Format: "plain_text", Text: o})
+			}
+		default:
+			return fmt.Errorf("question %d: %s questions are %w", i+1, q.Kind(), ErrUnsupported)
+		}
+		doc.Questions = append(doc.Questions, mq)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func fraction(correct bool) string {
+	if correct {
+		return "100"
+	}
+	return "0"
+}
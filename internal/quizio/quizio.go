@@ -0,0 +1,226 @@
+// Package quizio converts quizzes from and to the file formats creators keep
+// their questions in: JSON, CSV, Moodle GIFT and Moodle XML.
+package quizio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatGIFT Format = "gift"
+	FormatXML  Format = "xml" // Moodle XML
+)
+
+var Formats = []Format{FormatJSON, FormatCSV, FormatGIFT, FormatXML}
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrUnsupported   = errors.New("not supported by this format")
+)
+
+// Row is one question read from an import. Line is where the question starts:
+// the line for CSV and GIFT, the question position for JSON and Moodle XML.
+// Err is set when the question couldn't be read.
+type Row struct {
+	Line     int
+	Question model.Question
+	Err      error
+}
+
+// Import is a decoded file. Quiz carries the metadata the format provides; the
+// questions are in Rows.
+type Import struct {
+	Quiz model.Quiz
+	Rows []Row
+}
+
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Formats {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, s)
+}
+
+// FormatFromFilename guesses the format from a file extension.
+func FormatFromFilename(name string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return FormatJSON, true
+	case ".csv":
+		return FormatCSV, true
+	case ".gift", ".txt":
+		return FormatGIFT, true
+	case ".xml":
+		return FormatXML, true
+	}
+	return "", false
+}
+
+// FormatFromContentType guesses the format from a request Content-Type.
+func FormatFromContentType(contentType string) (Format, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "application/json":
+		return FormatJSON, true
+	case "text/csv":
+		return FormatCSV, true
+	case "application/xml", "text/xml":
+		return FormatXML, true
+	}
+	return "", false
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSON:
+		return "application/json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXML:
+		return "application/xml; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+func (f Format) Extension() string {
+	if f == FormatGIFT {
+		return "gift.txt"
+	}
+	return string(f)
+}
+
+// Decode reads a quiz. Malformed files fail as a whole, while problems with a
+// single question are reported on its row.
+func Decode(f Format, r io.Reader) (*Import, error) {
+	switch f {
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatGIFT:
+		return decodeGIFT(r)
+	case FormatXML:
+		return decodeMoodleXML(r)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownFormat, f)
+}
+
+// Encode writes a quiz, answer keys included.
+func Encode(f Format, w io.Writer, quiz *model.Quiz) error {
+	switch f {
+	case FormatJSON:
+		return encodeJSON(w, quiz)
+	case FormatCSV:
+		return encodeCSV(w, quiz)
+	case FormatGIFT:
+		return encodeGIFT(w, quiz)
+	case FormatXML:
+		return encodeMoodleXML(w, quiz)
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFormat, f)
+}
+
+var trueFalseOptions = []string{"True", "False"}
+
+// optionIndex finds an option by its text, ignoring case and surrounding spaces.
+func optionIndex(options []string, text string) int {
+	text = strings.TrimSpace(text)
+	for i, o := range options {
+		if strings.EqualFold(strings.TrimSpace(o), text) {
+			return i
+		}
+	}
+	return -1
+}
+
+func optionIndexes(options []string, texts []string) ([]int, error) {
+	indexes := make([]int, 0, len(texts))
+	for _, text := range texts {
+		i := optionIndex(options, text)
+		if i < 0 {
+			return nil, fmt.Errorf("answer %q is not one of the options", text)
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes, nil
+}
+
+// trueFalseAnswer reads a true/false answer given as a boolean or as one of the options.
+func trueFalseAnswer(options []string, text string) (int, error) {
+	if i := optionIndex(options, text); i >= 0 {
+		return i, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "true", "t", "yes":
+		return 0, nil
+	case "false", "f", "no":
+		return 1, nil
+	}
+	return 0, fmt.Errorf("answer %q is not true or false", text)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// splitList splits a list cell on unescaped "|", dropping blank entries. Other
+// backslashes are kept as written.
+func splitList(s string) []string {
+	var items []string
+	var item strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(item.String()); text != "" {
+			items = append(items, text)
+		}
+		item.Reset()
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\'):
+			i++
+			item.WriteByte(s[i])
+		case s[i] == '|':
+			flush()
+		default:
+			item.WriteByte(s[i])
+		}
+	}
+	flush()
+	return items
+}
+
+// joinList writes a list cell read back by splitList.
+func joinList(items []string) string {
+	escaped := make([]string, len(items))
+	for i, item := range items {
+		escaped[i] = listEscaper.Replace(item)
+	}
+	return strings.Join(escaped, "|")
+}
+
+var listEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`)
+
+func pick(options []string, indexes []int) []string {
+	texts := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		if i >= 0 && i < len(options) {
+			texts = append(texts, options[i])
+		}
+	}
+	return texts
+}
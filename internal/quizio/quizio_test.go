@@ -0,0 +1,251 @@
+package quizio
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+// roundTripQuestions holds one question of every type, with the optional
+// fields set.
+func roundTripQuestions() []model.Question {
+	return []model.Question{
+		{Type: model.QuestionSingleChoice, Text: "Capital of France?", Options: []string{"Paris", "Lyon", "Nice"}, Answer: 1, Explanation: "Trick question.", Reference: "https://example.com", Weight: 2},
+		{Type: model.QuestionMultiSelect, Text: "Pick the primes", Options: []string{"2", "4", "5"}, Answers: []int{0, 2}},
+		{Type: model.QuestionTrueFalse, Text: "Go has generics", Options: []string{"True", "False"}, Answer: 0, Explanation: "Since Go 1.18."},
+		{Type: model.QuestionNumeric, Text: "Value of pi?", NumericAnswer: 3.14, Tolerance: 0.01},
+		{Type: model.QuestionOrdering, Text: "Sort ascending", Options: []string{"3", "1", "2"}, Order: []int{1, 2, 0}},
+		{Type: model.QuestionShortText, Text: "Go's mascot?", AcceptedAnswers: []string{"gopher", "go gopher"}, Fuzzy: true},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		format Format
+		// expect turns a question into what the format reads back, and
+		// reports false for questions the format can't write
+		expect func(q *model.Question) bool
+	}{
+		{FormatJSON, func(q *model.Question) bool { return true }},
+		{FormatCSV, func(q *model.Question) bool { return true }},
+		{FormatGIFT, func(q *model.Question) bool {
+			q.Reference, q.Weight, q.Fuzzy = "", 0, false
+			return q.Type != model.QuestionOrdering
+		}},
+		{FormatXML, func(q *model.Question) bool {
+			q.Reference, q.Fuzzy = "", false
+			if q.Type == model.QuestionOrdering {
+				// options are written in the correct order
+				q.Options, q.Order = pick(q.Options, q.Order), []int{0, 1, 2}
+			}
+			return true
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			quiz := &model.Quiz{Title: "Mixed", Category: "General", Points: 10}
+			var want []model.Question
+			for _, q := range roundTripQuestions() {
+				expected := q
+				if !tt.expect(&expected) {
+					continue
+				}
+				quiz.Questions = append(quiz.Questions, q)
+				want = append(want, expected)
+			}
+
+			var buf bytes.Buffer
+			if err := Encode(tt.format, &buf, quiz); err != nil {
+				t.Fatal(err)
+			}
+			imp, err := Decode(tt.format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(imp.Rows) != len(want) {
+				t.Fatalf("got %d rows, want %d", len(imp.Rows), len(want))
+			}
+			for i, row := range imp.Rows {
+				if row.Err != nil {
+					t.Errorf("row %d: %v", row.Line, row.Err)
+					continue
+				}
+				if !reflect.DeepEqual(row.Question, want[i]) {
+					t.Errorf("question %d\n got %+v\nwant %+v", i+1, row.Question, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGIFTRejectsOrdering(t *testing.T) {
+	quiz := &model.Quiz{Questions: roundTripQuestions()[4:5]}
+	if err := Encode(FormatGIFT, &bytes.Buffer{}, quiz); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestHints(t *testing.T) {
+	eliminate := model.Hint{Type: model.HintEliminate, Option: 2}
+	clue := model.Hint{Type: model.HintClue, Text: "On the Seine | the river"}
+	tests := []struct {
+		format  Format
+		hints   []model.Hint
+		wantErr error
+	}{
+		{FormatJSON, []model.Hint{eliminate, clue}, nil},
+		{FormatCSV, []model.Hint{eliminate, clue}, nil},
+		{FormatXML, []model.Hint{clue}, nil},
+		{FormatXML, []model.Hint{clue, eliminate}, ErrUnsupported},
+		{FormatGIFT, []model.Hint{clue}, ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			question := model.Question{Type: model.QuestionSingleChoice, Text: "Capital of France?", Options: []string{"Paris", "Lyon", "Nice"}, Answer: 0, Hints: tt.hints}
+			var buf bytes.Buffer
+			err := Encode(tt.format, &buf, &model.Quiz{Questions: []model.Question{question}})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			imp, err := Decode(tt.format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := imp.Rows[0].Question.Hints; !reflect.DeepEqual(got, tt.hints) {
+				t.Errorf("got hints %+v, want %+v", got, tt.hints)
+			}
+		})
+	}
+}
+
+func TestCSVListSeparatorInOptions(t *testing.T) {
+	quiz := &model.Quiz{Questions: []model.Question{
+		{Type: model.QuestionMultiSelect, Text: "Shell operators", Options: []string{"a | b", `C:\dir`, "&&"}, Answers: []int{0, 1}},
+	}}
+	var buf bytes.Buffer
+	if err := Encode(FormatCSV, &buf, quiz); err != nil {
+		t.Fatal(err)
+	}
+	imp, err := Decode(FormatCSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := imp.Rows[0].Question; !reflect.DeepEqual(got.Options, quiz.Questions[0].Options) || !reflect.DeepEqual(got.Answers, quiz.Questions[0].Answers) {
+		t.Errorf("got options %q answers %v, want %q %v", got.Options, got.Answers, quiz.Questions[0].Options, quiz.Questions[0].Answers)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		input   string
+		want    model.Question
+		wantErr string
+	}{
+		{
+			name:   "csv question column, default type and unescaped backslash",
+			format: FormatCSV,
+			input:  "question,options,answer\nWhich path?,C:\\dir|D:\\,C:\\dir\n",
+			want:   model.Question{Type: model.QuestionSingleChoice, Text: "Which path?", Options: []string{`C:\dir`, `D:\`}},
+		},
+		{
+			name:   "csv true/false answered as a boolean",
+			format: FormatCSV,
+			input:  "type,text,answer\ntrue_false,Go has generics,no\n",
+			want:   model.Question{Type: model.QuestionTrueFalse, Text: "Go has generics", Options: trueFalseOptions, Answer: 1},
+		},
+		{
+			name:    "csv answer missing from the options",
+			format:  FormatCSV,
+			input:   "type,text,options,answer\nsingle_choice,Capital?,Paris|Lyon,Rome\n",
+			want:    model.Question{Type: model.QuestionSingleChoice, Text: "Capital?", Options: []string{"Paris", "Lyon"}, Answer: -1},
+			wantErr: `answer "Rome" is not one of the options`,
+		},
+		{
+			name:   "csv hints without a prefix are clues",
+			format: FormatCSV,
+			input:  "text,options,answer,hints\nCapital?,Paris|Lyon,Paris,eliminate: lyon|Big city\n",
+			want: model.Question{Type: model.QuestionSingleChoice, Text: "Capital?", Options: []string{"Paris", "Lyon"},
+				Hints: []model.Hint{{Type: model.HintEliminate, Option: 1}, {Type: model.HintClue, Text: "Big city"}}},
+		},
+		{
+			name:    "csv hint eliminating an unknown option",
+			format:  FormatCSV,
+			input:   "text,options,answer,hints\nCapital?,Paris|Lyon,Paris,eliminate:Rome\n",
+			want:    model.Question{Type: model.QuestionSingleChoice, Text: "Capital?", Options: []string{"Paris", "Lyon"}},
+			wantErr: `hint option "Rome" is not one of the options`,
+		},
+		{
+			name:   "gift numeric range",
+			format: FormatGIFT,
+			input:  "Number between one and two? {#1..2}\n",
+			want:   model.Question{Type: model.QuestionNumeric, Text: "Number between one and two?", NumericAnswer: 1.5, Tolerance: 0.5},
+		},
+		{
+			name:   "gift escaped characters and feedback",
+			format: FormatGIFT,
+			input:  "::Q1:: [markdown]1 \\= 1? {=yes#right ~no#wrong}\n",
+			want:   model.Question{Type: model.QuestionSingleChoice, Text: "1 = 1?", Options: []string{"yes", "no"}, Answer: 0},
+		},
+		{
+			name:    "gift matching",
+			format:  FormatGIFT,
+			input:   "Match {=a -> 1 =b -> 2}\n",
+			want:    model.Question{Text: "Match"},
+			wantErr: "matching questions are not supported",
+		},
+		{
+			name:   "moodle html question text",
+			format: FormatXML,
+			input:  `<quiz><question type="multichoice"><questiontext format="html"><text>&lt;p&gt;Capital &amp;amp; city?&lt;/p&gt;</text></questiontext><answer fraction="0"><text>Lyon</text></answer><answer fraction="100"><text>Paris</text></answer></question></quiz>`,
+			want:   model.Question{Type: model.QuestionSingleChoice, Text: "Capital & city?", Options: []string{"Lyon", "Paris"}, Answer: 1},
+		},
+		{
+			name:    "moodle unsupported type",
+			format:  FormatXML,
+			input:   `<quiz><question type="essay"><questiontext><text>Discuss</text></questiontext></question></quiz>`,
+			want:    model.Question{Text: "Discuss"},
+			wantErr: `moodle "essay" questions are not supported`,
+		},
+		{
+			name:   "json drops question ids",
+			format: FormatJSON,
+			input:  `{"title":"Q","questions":[{"id":"65a000000000000000000001","text":"Capital?","options":["Paris","Lyon"],"answer":0}]}`,
+			want:   model.Question{Text: "Capital?", Options: []string{"Paris", "Lyon"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp, err := Decode(tt.format, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(imp.Rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(imp.Rows))
+			}
+			row := imp.Rows[0]
+			if tt.wantErr == "" && row.Err != nil || tt.wantErr != "" && (row.Err == nil || row.Err.Error() != tt.wantErr) {
+				t.Errorf("got error %v, want %q", row.Err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(row.Question, tt.want) {
+				t.Errorf("got %+v\nwant %+v", row.Question, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatXML} {
+		if _, err := Decode(format, strings.NewReader("{<")); err == nil {
+			t.Errorf("%s: malformed file decoded without error", format)
+		}
+	}
+}
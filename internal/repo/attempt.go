@@ -0,0 +1,301 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AttemptRepo interface {
+	InitIndexes(ctx context.Context) error
+	Create(ctx context.Context, attempt *model.Attempt) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Attempt, error)
+	FindByUser(ctx context.Context, userID primitive.ObjectID, status model.AttemptStatus, page int64, limit int64) ([]model.Attempt, int64, error)
+	FindExpired(ctx context.Context, before time.Time, idleBefore time.Time, limit int64) ([]model.Attempt, error)
+	RecordSweepFailure(ctx context.Context, id primitive.ObjectID) (int, error)
+	SaveAnswers(ctx context.Context, id primitive.ObjectID, answers map[string]any, at time.Time) error
+	RevealHint(ctx context.Context, id primitive.ObjectID, position string, available int) (int, error)
+	Claim(ctx context.Context, id primitive.ObjectID) error
+	Release(ctx context.Context, id primitive.ObjectID) error
+	Submit(ctx context.Context, attempt *model.Attempt) error
+	Close(ctx context.Context, id primitive.ObjectID, status model.AttemptStatus, at time.Time) error
+}
+
+type attemptRepo struct {
+	collection *mongo.Collection
+}
+
+func NewAttemptRepo(db *mongo.Database) AttemptRepo {
+	repo := &attemptRepo{
+		collection: db.Collection("attempts"),
+	}
+	repo.InitIndexes(context.Background())
+	return repo
+}
+
+func (r *attemptRepo) InitIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "quiz_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexModels)
+	return err
+}
+
+func (r *attemptRepo) Create(ctx context.Context, attempt *model.Attempt) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	attempt.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, attempt)
+	return err
+}
+
+func (r *attemptRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Attempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var attempt model.Attempt
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&attempt)
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// FindByUser returns a page of the user's attempts in the given status, any status
+// when empty, newest first, without the per-question results, and the total number
+// of matching attempts.
+func (r *attemptRepo) FindByUser(ctx context.Context, userID primitive.ObjectID, status model.AttemptStatus, page int64, limit int64) ([]model.Attempt, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+	if status != "" {
+		filter["status"] = status
+	}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(limit).
+		SetProjection(bson.M{"results": 0, "questions": 0})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	attempts := []model.Attempt{}
+	if err = cursor.All(ctx, &attempts); err != nil {
+		return nil, 0, err
+	}
+	return attempts, total, nil
+}
+
+// FindExpired returns in-progress attempts whose deadline is before the given
+// time, and untimed ones neither started nor saved since idleBefore. Untimed
+// attempts come first, then the oldest deadlines.
+func (r *attemptRepo) FindExpired(ctx context.Context, before time.Time, idleBefore time.Time, limit int64) ([]model.Attempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status": model.AttemptInProgress,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lt": before}},
+			bson.M{
+				"expires_at": bson.M{"$exists": false},
+				"started_at": bson.M{"$lt": idleBefore},
+				"$or": bson.A{
+					bson.M{"saved_at": bson.M{"$exists": false}},
+					bson.M{"saved_at": bson.M{"$lt": idleBefore}},
+				},
+			},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}, {Key: "started_at", Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attempts := []model.Attempt{}
+	if err = cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// RecordSweepFailure counts one more failed attempt at closing an abandoned
+// attempt and returns the new count.
+func (r *attemptRepo) RecordSweepFailure(ctx context.Context, id primitive.ObjectID) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"sweep_failures": 1})
+
+	var attempt model.Attempt
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": model.AttemptInProgress}, bson.M{"$inc": bson.M{"sweep_failures": 1}}, opts).Decode(&attempt)
+	if err != nil {
+		return 0, err
+	}
+	return attempt.SweepFailures, nil
+}
+
+// SaveAnswers merges answers into the saved answers of an in-progress attempt, so
+// saves from several devices don't drop each other's answers. A nil answer clears
+// the saved one. It returns mongo.ErrNoDocuments if the attempt is closed.
+func (r *attemptRepo) SaveAnswers(ctx context.Context, id primitive.ObjectID, answers map[string]any, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{"saved_at": at}
+	unset := bson.M{}
+	for key, answer := range answers {
+		if answer == nil {
+			unset["answers."+key] = ""
+		} else {
+			set["answers."+key] = answer
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": model.AttemptInProgress}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RevealHint counts one more hint revealed for the question shown at position, as
+// long as fewer than available were revealed so far, and returns the new count. It
+// returns mongo.ErrNoDocuments if the attempt is closed or the hints ran out.
+func (r *attemptRepo) RevealHint(ctx context.Context, id primitive.ObjectID, position string, available int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	field := "hints_used." + position
+	filter := bson.M{
+		"_id":    id,
+		"status": model.AttemptInProgress,
+		field:    bson.M{"$not": bson.M{"$gte": available}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"hints_used": 1})
+
+	var attempt model.Attempt
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{field: 1}}, opts).Decode(&attempt)
+	if err != nil {
+		return 0, err
+	}
+	return attempt.HintsUsed[position], nil
+}
+
+// Claim marks an in-progress attempt as being graded, so that of several
+// submissions of the attempt only one goes on to count it. It returns
+// mongo.ErrNoDocuments if the attempt isn't in progress anymore.
+func (r *attemptRepo) Claim(ctx context.Context, id primitive.ObjectID) error {
+	return r.moveStatus(ctx, id, model.AttemptInProgress, model.AttemptGrading)
+}
+
+// Release puts a claimed attempt back in progress when its submission failed
+// before anything was counted.
+func (r *attemptRepo) Release(ctx context.Context, id primitive.ObjectID) error {
+	return r.moveStatus(ctx, id, model.AttemptGrading, model.AttemptInProgress)
+}
+
+func (r *attemptRepo) moveStatus(ctx context.Context, id primitive.ObjectID, from model.AttemptStatus, to model.AttemptStatus) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, bson.M{"$set": bson.M{"status": to}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Submit stores the graded results of a claimed attempt and marks it submitted.
+// It returns mongo.ErrNoDocuments if the attempt isn't claimed.
+func (r *attemptRepo) Submit(ctx context.Context, attempt *model.Attempt) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": attempt.ID, "status": model.AttemptGrading}
+	update := bson.M{"$set": bson.M{
+		"status":         model.AttemptSubmitted,
+		"ended_at":       attempt.EndedAt,
+		"score":          attempt.Score,
+		"speed_bonus":    attempt.SpeedBonus,
+		"correct":        attempt.Correct,
+		"percentage":     attempt.Percentage,
+		"results":        attempt.Results,
+		"auto_submitted": attempt.AutoSubmitted,
+	}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Close moves an in-progress attempt to its final status. The status guard in the
+// filter makes it atomic: it returns mongo.ErrNoDocuments if the attempt was already closed.
+func (r *attemptRepo) Close(ctx context.Context, id primitive.ObjectID, status model.AttemptStatus, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": model.AttemptInProgress}
+	update := bson.M{"$set": bson.M{
+		"status":   status,
+		"ended_at": at,
+	}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
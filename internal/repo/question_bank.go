@@ -0,0 +1,176 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuestionBankRepo interface {
+	InitIndexes(ctx context.Context) error
+	Create(ctx context.Context, question *model.BankQuestion) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.BankQuestion, error)
+	FindPage(ctx context.Context, filter BankFilter, page int64, limit int64) ([]model.BankQuestion, int64, error)
+	FindMatching(ctx context.Context, filter BankFilter) ([]model.BankQuestion, error)
+	Update(ctx context.Context, question *model.BankQuestion) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// BankFilter narrows a user's question bank. Zero fields don't filter.
+type BankFilter struct {
+	UserID     primitive.ObjectID
+	Tags       []string // questions must carry every tag
+	Difficulty string
+}
+
+func (f BankFilter) bson() bson.M {
+	filter := bson.M{"user_id": f.UserID}
+	if len(f.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": f.Tags}
+	}
+	if f.Difficulty != "" {
+		filter["difficulty"] = f.Difficulty
+	}
+	return filter
+}
+
+type questionBankRepo struct {
+	collection *mongo.Collection
+}
+
+func NewQuestionBankRepo(db *mongo.Database) QuestionBankRepo {
+	repo := &questionBankRepo{
+		collection: db.Collection("question_bank"),
+	}
+	repo.InitIndexes(context.Background())
+	return repo
+}
+
+func (r *questionBankRepo) InitIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "tags", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "difficulty", Value: 1}},
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexModels)
+	return err
+}
+
+func (r *questionBankRepo) Create(ctx context.Context, question *model.BankQuestion) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	question.ID = primitive.NewObjectID()
+	question.CreatedAt = time.Now()
+	question.UpdatedAt = question.CreatedAt
+	_, err := r.collection.InsertOne(ctx, question)
+	return err
+}
+
+func (r *questionBankRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.BankQuestion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var question model.BankQuestion
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&question)
+	if err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+// FindPage returns a page of the bank, newest first, and the total number of matches.
+func (r *questionBankRepo) FindPage(ctx context.Context, filter BankFilter, page int64, limit int64) ([]model.BankQuestion, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := filter.bson()
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	questions := []model.BankQuestion{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, 0, err
+	}
+	return questions, total, nil
+}
+
+// FindMatching returns every question matching the filter in a stable order, so
+// that a seeded draw over them is reproducible.
+func (r *questionBankRepo) FindMatching(ctx context.Context, filter BankFilter) ([]model.BankQuestion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter.bson(), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	questions := []model.BankQuestion{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (r *questionBankRepo) Update(ctx context.Context, question *model.BankQuestion) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	question.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"question":   question.Question,
+		"tags":       question.Tags,
+		"difficulty": question.Difficulty,
+		"updated_at": question.UpdatedAt,
+	}}
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": question.ID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete removes a question from the bank. Attempts that were served it keep their own copy.
+func (r *questionBankRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -13,11 +13,129 @@ import (
 )
 
 type QuizRepo interface {
+	InitIndexes(ctx context.Context) error
 	Create(ctx context.Context, quiz *model.Quiz) error
 	FindByID(ctx context.Context, quiz_id primitive.ObjectID) (*model.Quiz, error)
 	FindAll(ctx context.Context) ([]model.Quiz, error)
 	FindAllByUser(ctx context.Context, user_id primitive.ObjectID) ([]model.Quiz, error)
 	FindByCategory(ctx context.Context, category string) ([]model.Quiz, error)
+	Update(ctx context.Context, quiz *model.Quiz, version int) error
+	SoftDelete(ctx context.Context, id primitive.ObjectID) error
+	FindPage(ctx context.Context, filter QuizFilter, sort string, page int64, limit int64, summary bool) ([]model.Quiz, int64, error)
+	IncrementAttemptCount(ctx context.Context, id primitive.ObjectID) error
+	Search(ctx context.Context, text string, page int64, limit int64) ([]QuizSearchHit, int64, error)
+	SetStatus(ctx context.Context, id primitive.ObjectID, from model.QuizStatus, to model.QuizStatus, publishAt *time.Time) error
+	FindDueScheduled(ctx context.Context, now time.Time) ([]model.Quiz, error)
+}
+
+// QuizSearchHit is a quiz matched by a full-text search with its relevance score.
+// Questions only carry their text.
+type QuizSearchHit struct {
+	model.Quiz `bson:",inline"`
+	Score      float64 `bson:"score"`
+}
+
+// Catalog sort orders.
+const (
+	QuizSortNewest  = "newest"
+	QuizSortPopular = "popular"
+	QuizSortRating  = "rating"
+)
+
+// QuizFilter narrows the catalog. Zero fields don't filter.
+type QuizFilter struct {
+	Category   string
+	Difficulty string
+	Tags       []string // quizzes must carry every tag
+	CreatorID  primitive.ObjectID
+	IDs        []primitive.ObjectID // when non-nil, only these quizzes
+	ExcludeIDs []primitive.ObjectID
+	Statuses   []model.QuizStatus // published and listed only when empty
+}
+
+func (f QuizFilter) bson() bson.M {
+	filter := withPublic(bson.M{})
+	if len(f.Statuses) > 0 {
+		// Reviewers see unlisted and private quizzes in their queue too
+		filter["status"] = statusFilter(f.Statuses...)
+		delete(filter, "visibility")
+	}
+	if f.Category != "" {
+		filter["category"] = f.Category
+	}
+	if f.Difficulty != "" {
+		filter["difficulty"] = f.Difficulty
+	}
+	if len(f.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": f.Tags}
+	}
+	if !f.CreatorID.IsZero() {
+		filter["user_id"] = f.CreatorID
+	}
+	ids := bson.M{}
+	if f.IDs != nil {
+		ids["$in"] = f.IDs
+	}
+	if len(f.ExcludeIDs) > 0 {
+		ids["$nin"] = f.ExcludeIDs
+	}
+	if len(ids) > 0 {
+		filter["_id"] = ids
+	}
+	return filter
+}
+
+// summaryProjection leaves the questions out of catalog listings and only counts them.
+var summaryProjection = bson.M{
+	"title":          1,
+	"category":       1,
+	"description":    1,
+	"difficulty":     1,
+	"tags":           1,
+	"points":         1,
+	"time_limit":     1,
+	"quiz_id":        1,
+	"user_id":        1,
+	"attempt_count":  1,
+	"average_rating": 1,
+	"rating_count":   1,
+	"access_tier":    1,
+	"status":         1,
+	"publish_at":     1,
+	"created_at":     1,
+	"updated_at":     1,
+	"question_count": bson.M{"$size": bson.M{"$ifNull": bson.A{"$questions", bson.A{}}}},
+}
+
+// notDeleted filters out soft deleted quizzes.
+var notDeleted = bson.M{"deleted_at": bson.M{"$exists": false}}
+
+func withNotDeleted(filter bson.M) bson.M {
+	for k, v := range notDeleted {
+		filter[k] = v
+	}
+	return filter
+}
+
+// statusFilter matches quizzes in the given status. Quizzes created before the
+// publication workflow have no status and count as published.
+func statusFilter(statuses ...model.QuizStatus) bson.M {
+	values := bson.A{}
+	for _, status := range statuses {
+		values = append(values, status)
+		if status == model.QuizPublished {
+			values = append(values, nil)
+		}
+	}
+	return bson.M{"$in": values}
+}
+
+// withPublic restricts a catalog query to published quizzes that are listed.
+// Quizzes created before visibility settings have none and are public.
+func withPublic(filter bson.M) bson.M {
+	filter["status"] = statusFilter(model.QuizPublished)
+	filter["visibility"] = bson.M{"$in": bson.A{model.VisibilityPublic, nil}}
+	return withNotDeleted(filter)
 }
 
 type quizRepo struct {
@@ -25,9 +143,54 @@ type quizRepo struct {
 }
 
 func NewQuizRepo(db *mongo.Database) *quizRepo {
-	return &quizRepo{
+	repo := &quizRepo{
 		collection: db.Collection("quizzes", &options.CollectionOptions{}),
 	}
+	repo.InitIndexes(context.Background())
+	return repo
+}
+
+func (r *quizRepo) InitIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "category", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tags", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "attempt_count", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "average_rating", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "publish_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{
+				{Key: "title", Value: "text"},
+				{Key: "description", Value: "text"},
+				{Key: "category", Value: "text"},
+				{Key: "questions.text", Value: "text"},
+			},
+			Options: options.Index().SetName("quiz_text").SetWeights(bson.D{
+				{Key: "title", Value: 10},
+				{Key: "category", Value: 5},
+				{Key: "description", Value: 3},
+				{Key: "questions.text", Value: 1},
+			}),
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexModels)
+	return err
 }
 
 func (r *quizRepo) Create(ctx context.Context, quiz *model.Quiz) error {
@@ -56,10 +219,10 @@ func (r *quizRepo) FindByID(ctx context.Context, quiz_id primitive.ObjectID) (*m
 	var rawResult bson.M
 	
 	// Try to find by quiz_id first, then fallback to _id
-	err := r.collection.FindOne(ctx, bson.M{"quiz_id": quiz_id}).Decode(&rawResult)
+	err := r.collection.FindOne(ctx, withNotDeleted(bson.M{"quiz_id": quiz_id})).Decode(&rawResult)
 	if err != nil {
 		// Fallback to _id if quiz_id doesn't match
-		err = r.collection.FindOne(ctx, bson.M{"_id": quiz_id}).Decode(&rawResult)
+		err = r.collection.FindOne(ctx, withNotDeleted(bson.M{"_id": quiz_id})).Decode(&rawResult)
 		if err != nil {
 			return nil, err
 		}
@@ -123,7 +286,7 @@ func (r *quizRepo) FindAll(ctx context.Context) ([]model.Quiz, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	cursor, err := r.collection.Find(ctx, withPublic(bson.M{}))
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +303,7 @@ func (r *quizRepo) FindAllByUser(ctx context.Context, user_id primitive.ObjectID
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	cursor, err := r.collection.Find(ctx, bson.M{"user_id": user_id})
+	cursor, err := r.collection.Find(ctx, withNotDeleted(bson.M{"user_id": user_id}))
 	if err != nil {
 		return nil, err
 	}
@@ -157,7 +320,210 @@ func (r *quizRepo) FindByCategory(ctx context.Context, category string) ([]model
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	cursor, err := r.collection.Find(ctx, bson.M{"category": category})
+	cursor, err := r.collection.Find(ctx, withPublic(bson.M{"category": category}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var quizzes []model.Quiz
+	if err = cursor.All(ctx, &quizzes); err != nil {
+		return nil, err
+	}
+	return quizzes, nil
+}
+
+// Update replaces the quiz content if it is still at the given version, so two
+// concurrent edits can't silently overwrite each other.
+func (r *quizRepo) Update(ctx context.Context, quiz *model.Quiz, version int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	for i := range quiz.Questions {
+		if quiz.Questions[i].ID.IsZero() {
+			quiz.Questions[i].ID = primitive.NewObjectID()
+		}
+	}
+
+	filter := withNotDeleted(bson.M{"_id": quiz.ID})
+	if version > 0 {
+		filter["version"] = version
+	} else {
+		// quizzes created before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	update := bson.M{"$set": bson.M{
+		"title":       quiz.Title,
+		"category":    quiz.Category,
+		"description": quiz.Description,
+		"difficulty":  quiz.Difficulty,
+		"tags":        quiz.Tags,
+		"questions":   quiz.Questions,
+		"rules":       quiz.Rules,
+		"points":      quiz.Points,
+		"time_limit":  quiz.TimeLimit,
+		"retake":      quiz.Retake,
+		"scoring":     quiz.Scoring,
+		"keep_order":  quiz.KeepOrder,
+		"access_tier": quiz.AccessTier,
+		"visibility":  quiz.Visibility,
+		"status":      quiz.Status,
+		"version":     quiz.Version,
+		"updated_at":  quiz.UpdatedAt,
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SoftDelete hides the quiz from every read. Attempts and versions are kept.
+func (r *quizRepo) SoftDelete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx,
+		withNotDeleted(bson.M{"_id": id}),
+		bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FindPage returns one page of the catalog and the total number of matching quizzes.
+// With summary set, questions are left out and only counted.
+func (r *quizRepo) FindPage(ctx context.Context, filter QuizFilter, sort string, page int64, limit int64, summary bool) ([]model.Quiz, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := filter.bson()
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order := bson.D{{Key: "created_at", Value: -1}}
+	switch sort {
+	case QuizSortPopular:
+		order = bson.D{{Key: "attempt_count", Value: -1}, {Key: "created_at", Value: -1}}
+	case QuizSortRating:
+		order = bson.D{{Key: "average_rating", Value: -1}, {Key: "rating_count", Value: -1}}
+	}
+	// _id keeps the order stable between pages
+	order = append(order, bson.E{Key: "_id", Value: -1})
+
+	skip := (page - 1) * limit
+	opts := options.Find().SetSort(order).SetSkip(skip).SetLimit(limit)
+	if summary {
+		opts.SetProjection(summaryProjection)
+	}
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	quizzes := []model.Quiz{}
+	if err = cursor.All(ctx, &quizzes); err != nil {
+		return nil, 0, err
+	}
+	return quizzes, total, nil
+}
+
+// IncrementAttemptCount bumps the popularity counter of a quiz.
+func (r *quizRepo) IncrementAttemptCount(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"attempt_count": 1}})
+	return err
+}
+
+// Search runs a full-text search over title, description, category and question
+// text, most relevant first.
+func (r *quizRepo) Search(ctx context.Context, text string, page int64, limit int64) ([]QuizSearchHit, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := withPublic(bson.M{"$text": bson.M{"$search": text}})
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	projection := bson.M{"questions.text": 1, "score": bson.M{"$meta": "textScore"}}
+	for field, v := range summaryProjection {
+		if field != "question_count" {
+			projection[field] = v
+		}
+	}
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	hits := []QuizSearchHit{}
+	if err = cursor.All(ctx, &hits); err != nil {
+		return nil, 0, err
+	}
+	return hits, total, nil
+}
+
+// SetStatus moves a quiz from one status to another. It fails with
+// mongo.ErrNoDocuments if the quiz isn't in the from status anymore, so
+// concurrent transitions can't both succeed.
+func (r *quizRepo) SetStatus(ctx context.Context, id primitive.ObjectID, from model.QuizStatus, to model.QuizStatus, publishAt *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{"status": to, "updated_at": now}
+	if publishAt != nil {
+		set["publish_at"] = publishAt
+	}
+	if to == model.QuizPublished {
+		set["published_at"] = now
+	}
+	result, err := r.collection.UpdateOne(ctx,
+		withNotDeleted(bson.M{"_id": id, "status": statusFilter(from)}),
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FindDueScheduled returns the scheduled quizzes whose publication time has come.
+func (r *quizRepo) FindDueScheduled(ctx context.Context, now time.Time) ([]model.Quiz, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := withNotDeleted(bson.M{
+		"status":     model.QuizScheduled,
+		"publish_at": bson.M{"$lte": now},
+	})
+	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
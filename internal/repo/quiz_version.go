@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuizVersionRepo interface {
+	InitIndexes(ctx context.Context) error
+	Create(ctx context.Context, version *model.QuizVersion) error
+	FindByQuiz(ctx context.Context, quizID primitive.ObjectID) ([]model.QuizVersion, error)
+	FindOne(ctx context.Context, quizID primitive.ObjectID, version int) (*model.QuizVersion, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type quizVersionRepo struct {
+	collection *mongo.Collection
+}
+
+func NewQuizVersionRepo(db *mongo.Database) QuizVersionRepo {
+	repo := &quizVersionRepo{
+		collection: db.Collection("quiz_versions"),
+	}
+	repo.InitIndexes(context.Background())
+	return repo
+}
+
+func (r *quizVersionRepo) InitIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "quiz_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// Create stores a snapshot. Snapshots are never updated afterwards.
+func (r *quizVersionRepo) Create(ctx context.Context, version *model.QuizVersion) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	version.ID = primitive.NewObjectID()
+	if version.CreatedAt.IsZero() {
+		version.CreatedAt = time.Now()
+	}
+	_, err := r.collection.InsertOne(ctx, version)
+	return err
+}
+
+// FindByQuiz lists the versions of a quiz, newest first.
+func (r *quizVersionRepo) FindByQuiz(ctx context.Context, quizID primitive.ObjectID) ([]model.QuizVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"quiz_id": quizID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	versions := []model.QuizVersion{}
+	if err = cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *quizVersionRepo) FindOne(ctx context.Context, quizID primitive.ObjectID, version int) (*model.QuizVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var v model.QuizVersion
+	err := r.collection.FindOne(ctx, bson.M{"quiz_id": quizID, "version": version}).Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Delete discards a snapshot whose content never became the quiz's current content.
+func (r *quizVersionRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
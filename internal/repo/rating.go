@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RatingRepo interface {
+	InitIndexes(ctx context.Context) error
+	Upsert(ctx context.Context, rating *model.Rating) error
+	RefreshQuizRating(ctx context.Context, quizID primitive.ObjectID) error
+	FindByQuiz(ctx context.Context, quizID primitive.ObjectID, withReview bool, page int64, limit int64) ([]model.Rating, int64, error)
+}
+
+type ratingRepo struct {
+	collection *mongo.Collection
+}
+
+func NewRatingRepo(db *mongo.Database) RatingRepo {
+	repo := &ratingRepo{
+		collection: db.Collection("ratings"),
+	}
+	repo.InitIndexes(context.Background())
+	return repo
+}
+
+func (r *ratingRepo) InitIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	indexModels := []mongo.IndexModel{
+		{
+			// One rating per user and quiz
+			Keys:    bson.D{{Key: "quiz_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "quiz_id", Value: 1}, {Key: "updated_at", Value: -1}},
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexModels)
+	return err
+}
+
+// Upsert stores the user's rating of the quiz, replacing the one they gave before.
+func (r *ratingRepo) Upsert(ctx context.Context, rating *model.Rating) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	id := primitive.NewObjectID()
+	filter := bson.M{"quiz_id": rating.QuizID, "user_id": rating.UserID}
+	update := bson.M{
+		"$set": bson.M{
+			"user_name":  rating.UserName,
+			"stars":      rating.Stars,
+			"review":     rating.Review,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{"_id": id, "created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	var previous model.Rating
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&previous)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		rating.ID, rating.CreatedAt, rating.UpdatedAt = id, now, now
+		return nil
+	case err != nil:
+		return err
+	}
+	rating.ID, rating.CreatedAt, rating.UpdatedAt = previous.ID, previous.CreatedAt, now
+	return nil
+}
+
+// RefreshQuizRating recomputes the quiz's average_rating and rating_count from
+// its ratings and merges them into the quiz, in a single aggregation run by the
+// server. Counters are never adjusted by deltas, so a failed or concurrent
+// rating can't leave them out of step with the ratings.
+func (r *ratingRepo) RefreshQuizRating(ctx context.Context, quizID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"quiz_id": quizID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$quiz_id",
+			"average":      bson.M{"$avg": "$stars"},
+			"rating_count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"average_rating": bson.M{"$round": bson.A{"$average", 2}},
+			"rating_count":   1,
+		}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           "quizzes",
+			"on":             "_id",
+			"whenMatched":    "merge",
+			"whenNotMatched": "discard",
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// FindByQuiz returns a page of the quiz's ratings, most recently updated first.
+// withReview keeps only the ratings that came with a review.
+func (r *ratingRepo) FindByQuiz(ctx context.Context, quizID primitive.ObjectID, withReview bool, page int64, limit int64) ([]model.Rating, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"quiz_id": quizID}
+	if withReview {
+		filter["review"] = bson.M{"$gt": ""}
+	}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	ratings := []model.Rating{}
+	if err = cursor.All(ctx, &ratings); err != nil {
+		return nil, 0, err
+	}
+	return ratings, total, nil
+}
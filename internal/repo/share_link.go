@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShareLinkRepo interface {
+	InitIndexes(ctx context.Context) error
+	Create(ctx context.Context, link *model.ShareLink) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.ShareLink, error)
+	FindByQuiz(ctx context.Context, quizID primitive.ObjectID) ([]model.ShareLink, error)
+	Revoke(ctx context.Context, id primitive.ObjectID, quizID primitive.ObjectID) error
+}
+
+type shareLinkRepo struct {
+	collection *mongo.Collection
+}
+
+func NewShareLinkRepo(db *mongo.Database) ShareLinkRepo {
+	repo := &shareLinkRepo{
+		collection: db.Collection("share_links"),
+	}
+	repo.InitIndexes(context.Background())
+	return repo
+}
+
+func (r *shareLinkRepo) InitIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "quiz_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexModels)
+	return err
+}
+
+func (r *shareLinkRepo) Create(ctx context.Context, link *model.ShareLink) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	link.ID = primitive.NewObjectID()
+	link.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, link)
+	return err
+}
+
+func (r *shareLinkRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.ShareLink, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var link model.ShareLink
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&link)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// FindByQuiz returns every link of a quiz, revoked ones included, newest first.
+func (r *shareLinkRepo) FindByQuiz(ctx context.Context, quizID primitive.ObjectID) ([]model.ShareLink, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"quiz_id": quizID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	links := []model.ShareLink{}
+	if err = cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// Revoke stamps a link of the quiz as revoked. It fails with mongo.ErrNoDocuments
+// if the link doesn't exist or was already revoked.
+func (r *shareLinkRepo) Revoke(ctx context.Context, id primitive.ObjectID, quizID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "quiz_id": quizID, "revoked_at": bson.M{"$exists": false}}
+	res, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -17,9 +17,10 @@ type UserRepo interface {
 	UpdateRefreshToken(ctx context.Context, userID primitive.ObjectID, refreshToken string) error
 	FindByEmail(ctx context.Context, email string) (*model.User, error)
 	FindByID(ctx context.Context, id primitive.ObjectID) (*model.User, error)
-	UpdateStats(ctx context.Context, userID primitive.ObjectID, score int, completedQuizzes int, averageScore float64, streak int, activity map[string]int, completedQuizIDs []primitive.ObjectID) error
+	UpdateStats(ctx context.Context, user *model.User, version int) error
 	UpdateScore(ctx context.Context, userID primitive.ObjectID, score int) error
 	GetTopUsers(ctx context.Context, page int64, limit int64) ([]model.User, int64, error)
+	UpdateRole(ctx context.Context, userID primitive.ObjectID, role model.Role) error
 }
 
 type userRepoImpl struct {
@@ -111,23 +112,41 @@ func (r *userRepoImpl) FindByID(ctx context.Context, id primitive.ObjectID) (*mo
 	return &user, nil
 }
 
-func (r *userRepoImpl) UpdateStats(ctx context.Context, userID primitive.ObjectID, score int, completedQuizzes int, averageScore float64, streak int, activity map[string]int, completedQuizIDs []primitive.ObjectID) error {
+// UpdateStats stores the user's stats if they are still at the given version, and
+// bumps the version. It fails with mongo.ErrNoDocuments if another update got
+// there first, so concurrent submissions can't overwrite each other.
+func (r *userRepoImpl) UpdateStats(ctx context.Context, user *model.User, version int) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"user_id": userID}
+	filter := bson.M{"user_id": user.UserId}
+	if version > 0 {
+		filter["stats_version"] = version
+	} else {
+		// users created before stats versioning have no stats_version field
+		filter["stats_version"] = bson.M{"$in": bson.A{0, nil}}
+	}
 	update := bson.M{"$set": bson.M{
-		"score":              score,
-		"completed_quizzes":  completedQuizzes,
-		"average_score":      averageScore,
-		"streak":             streak,
-		"activity":           activity,
-		"completed_quiz_ids": completedQuizIDs,
+		"score":              user.Score,
+		"completed_quizzes":  len(user.CompletedQuizIDs),
+		"average_score":      user.AverageScore,
+		"streak":             user.Streak,
+		"activity":           user.Activity,
+		"completed_quiz_ids": user.CompletedQuizIDs,
+		"quiz_stats":         user.QuizStats,
+		"stats_version":      version + 1,
 		"updated_at":         time.Now(),
 	}}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	user.StatsVersion = version + 1
+	return nil
 }
 
 func (r *userRepoImpl) UpdateScore(ctx context.Context, userID primitive.ObjectID, score int) error {
@@ -170,3 +189,23 @@ func (r *userRepoImpl) GetTopUsers(ctx context.Context, page int64, limit int64)
 	}
 	return users, total, nil
 }
+
+func (r *userRepoImpl) UpdateRole(ctx context.Context, userID primitive.ObjectID, role model.Role) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+	update := bson.M{"$set": bson.M{
+		"role":       role,
+		"updated_at": time.Now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNotQuizOwner    = errors.New("not allowed to manage this quiz")
+	ErrUpgradeRequired = errors.New("upgrade required")
+)
+
+// Actor is the authenticated user behind a management request.
+type Actor struct {
+	UserID primitive.ObjectID
+	Role   model.Role
+}
+
+func NewActor(userID primitive.ObjectID, role string) Actor {
+	return Actor{UserID: userID, Role: model.Role(role)}
+}
+
+// canManageQuiz reports whether the actor may edit or inspect the answer keys
+// of a quiz. Quizzes without an owner can only be managed by admins.
+func canManageQuiz(actor Actor, quiz *model.Quiz) bool {
+	return actor.Role == model.RoleAdmin || quiz.OwnedBy(actor.UserID)
+}
+
+// canDeleteQuiz also lets moderators take down quizzes they don't own.
+func canDeleteQuiz(actor Actor, quiz *model.Quiz) bool {
+	return actor.Role == model.RoleModerator || canManageQuiz(actor, quiz)
+}
+
+// userTier returns the access tier unlocked by the user's subscription, looked up
+// the same way room creation checks it. Anonymous users and users without an
+// active subscription are on the free tier.
+func userTier(ctx context.Context, subscriptions SubscriptionService, userID primitive.ObjectID) model.AccessTier {
+	if subscriptions == nil || userID.IsZero() {
+		return model.TierFree
+	}
+	sub, err := subscriptions.GetSubscription(ctx, userID.Hex())
+	if err != nil || sub == nil || !sub.IsActive() {
+		return model.TierFree
+	}
+	return sub.Tier()
+}
+
+// isLocked reports whether the quiz is out of the actor's plan. The people
+// managing or reviewing a quiz always have access to it.
+func isLocked(actor Actor, tier model.AccessTier, quiz *model.Quiz) bool {
+	return !tier.Includes(quiz.Tier()) && !canManageQuiz(actor, quiz) && !isReviewer(actor)
+}
+
+// checkQuizAccess refuses quizzes out of the actor's plan with an upgrade error.
+func checkQuizAccess(ctx context.Context, subscriptions SubscriptionService, actor Actor, quiz *model.Quiz) error {
+	if quiz.Tier() == model.TierFree {
+		return nil
+	}
+	if isLocked(actor, userTier(ctx, subscriptions, actor.UserID), quiz) {
+		return fmt.Errorf("%w: this quiz needs the %s plan", ErrUpgradeRequired, quiz.Tier())
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// submissionGrace absorbs network latency between the client's deadline and the server.
+const submissionGrace = 5 * time.Second
+
+var (
+	ErrAttemptNotFound      = errors.New("attempt not found")
+	ErrAttemptClosed        = errors.New("attempt is already closed")
+	ErrAttemptExpired       = errors.New("attempt time limit exceeded")
+	ErrQuizAlreadyAttempted = errors.New("quiz already attempted")
+	ErrRetakeLimitReached   = errors.New("maximum number of attempts reached")
+	ErrRetakeCooldown       = errors.New("retake cooldown active")
+	ErrAttemptNotSubmitted  = errors.New("the review is available once the attempt is submitted")
+	ErrAttemptMode          = errors.New("practice attempts are submitted to /practice and ranked ones to /submit")
+	ErrInvalidAnswers       = errors.New("invalid answers")
+	ErrQuestionNotFound     = errors.New("question not found in the attempt")
+	ErrNoHintsLeft          = errors.New("no hints left for this question")
+)
+
+type AttemptService struct {
+	attemptRepo   repo.AttemptRepo
+	quizRepo      repo.QuizRepo
+	userRepo      repo.UserRepo
+	versionRepo   repo.QuizVersionRepo
+	questionBank  *QuestionBankService
+	shares        *ShareService
+	subscriptions SubscriptionService
+}
+
+func NewAttemptService(attemptRepo repo.AttemptRepo, quizRepo repo.QuizRepo, userRepo repo.UserRepo, versionRepo repo.QuizVersionRepo, questionBank *QuestionBankService, shares *ShareService, subscriptions SubscriptionService) *AttemptService {
+	return &AttemptService{
+		attemptRepo:   attemptRepo,
+		quizRepo:      quizRepo,
+		userRepo:      userRepo,
+		versionRepo:   versionRepo,
+		questionBank:  questionBank,
+		shares:        shares,
+		subscriptions: subscriptions,
+	}
+}
+
+// StartAttempt opens a new attempt stamped with the server time. If the quiz has a
+// time limit the attempt gets a deadline, and if it has rules the attempt draws
+// its own questions from the question bank. Unless the quiz keeps its order, each
+// attempt gets its own question and option order. Unlisted and private quizzes
+// need the share token the player opened them with, unless the actor manages or
+// reviews the quiz. Practice attempts never count, so the retake policy doesn't
+// apply to them.
+func (s *AttemptService) StartAttempt(ctx context.Context, actor Actor, quizID primitive.ObjectID, shareToken string, practice bool) (*model.Attempt, error) {
+	quiz, err := s.quizRepo.FindByID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	if !quiz.IsPublic() {
+		return nil, ErrQuizNotFound
+	}
+	if err := s.shares.CheckVisibility(ctx, actor, quiz, shareToken); err != nil {
+		return nil, err
+	}
+	if err := checkQuizAccess(ctx, s.subscriptions, actor, quiz); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !practice {
+		user, err := s.userRepo.FindByID(ctx, actor.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRetakePolicy(quiz, user, now); err != nil {
+			return nil, err
+		}
+	}
+
+	attempt := &model.Attempt{
+		QuizID:      quiz.ID,
+		QuizTitle:   quiz.Title,
+		QuizVersion: quiz.Version,
+		UserID:      actor.UserID,
+		Status:      model.AttemptInProgress,
+		Practice:    practice,
+		TimeLimit:   quiz.TimeLimit,
+		StartedAt:   now,
+	}
+	if quiz.TimeLimit > 0 {
+		expiresAt := now.Add(time.Duration(quiz.TimeLimit) * time.Second)
+		attempt.ExpiresAt = &expiresAt
+	}
+	attempt.Seed = rand.Int64()
+	questions := quiz.Questions
+	if quiz.HasRules() {
+		served, err := s.questionBank.AssembleQuiz(ctx, quiz, attempt.Seed)
+		if err != nil {
+			return nil, err
+		}
+		attempt.Questions = served.Questions
+		questions = served.Questions
+	}
+	attempt.QuestionCount = len(questions)
+	shuffleAttempt(attempt, questions, quiz.KeepOrder)
+
+	if err := s.attemptRepo.Create(ctx, attempt); err != nil {
+		return nil, err
+	}
+	attempt.Serve(questions)
+	attempt.RemainingAt(now)
+	return attempt, nil
+}
+
+// ListAttempts returns a page of the user's attempt history, newest first. An empty
+// status lists attempts in any state.
+func (s *AttemptService) ListAttempts(ctx context.Context, userID primitive.ObjectID, status model.AttemptStatus, page int64, limit int64) ([]model.Attempt, int64, error) {
+	return s.attemptRepo.FindByUser(ctx, userID, status, page, limit)
+}
+
+// SaveAnswers stores answers of an open attempt, keyed by question position like a
+// submission. They are merged into the answers saved so far, and a null answer
+// clears one. Saving doesn't extend the deadline.
+func (s *AttemptService) SaveAnswers(ctx context.Context, userID primitive.ObjectID, attemptID primitive.ObjectID, answers map[string]any) (*model.Attempt, error) {
+	now := time.Now()
+	attempt, err := s.runningAttempt(ctx, userID, attemptID, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("%w: no answers to save", ErrInvalidAnswers)
+	}
+	for key := range answers {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || (attempt.QuestionCount > 0 && i >= attempt.QuestionCount) {
+			return nil, fmt.Errorf("%w: %q is not a question position", ErrInvalidAnswers, key)
+		}
+	}
+
+	if err := s.attemptRepo.SaveAnswers(ctx, attempt.ID, answers, now); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrAttemptClosed
+		}
+		return nil, err
+	}
+
+	if attempt.Answers == nil {
+		attempt.Answers = map[string]any{}
+	}
+	for key, answer := range answers {
+		if answer == nil {
+			delete(attempt.Answers, key)
+		} else {
+			attempt.Answers[key] = answer
+		}
+	}
+	attempt.SavedAt = &now
+	attempt.RemainingAt(now)
+	return attempt, nil
+}
+
+// ResumeAttempt serves an open attempt again after a disconnect: the same questions
+// in the same order, the answers saved so far and the time left.
+func (s *AttemptService) ResumeAttempt(ctx context.Context, userID primitive.ObjectID, attemptID primitive.ObjectID) (*model.Attempt, error) {
+	now := time.Now()
+	attempt, err := s.runningAttempt(ctx, userID, attemptID, now)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.attemptQuestions(ctx, attempt)
+	if err != nil {
+		return nil, err
+	}
+	attempt.Serve(questions)
+	attempt.RemainingAt(now)
+	return attempt, nil
+}
+
+// HintReveal is a hint revealed during an attempt.
+type HintReveal struct {
+	AttemptID primitive.ObjectID `json:"attempt_id"`
+	Question  int                `json:"question"` // position the question is shown at
+	Hint      model.Hint         `json:"hint"`
+	HintsUsed int                `json:"hints_used"`
+	HintsLeft int                `json:"hints_left"`
+}
+
+// RevealHint reveals the next hint of the question shown at position and records
+// it on the attempt, so grading can deduct it. Eliminated options refer to the
+// options as the attempt shows them.
+func (s *AttemptService) RevealHint(ctx context.Context, userID primitive.ObjectID, attemptID primitive.ObjectID, position int) (*HintReveal, error) {
+	attempt, err := s.runningAttempt(ctx, userID, attemptID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.attemptQuestions(ctx, attempt)
+	if err != nil {
+		return nil, err
+	}
+	if position < 0 || position >= len(questions) {
+		return nil, ErrQuestionNotFound
+	}
+	hints := attempt.Displayed(questions)[position].Hints
+	key := strconv.Itoa(position)
+	if attempt.HintsUsed[key] >= len(hints) {
+		return nil, ErrNoHintsLeft
+	}
+
+	// Guarded on the count, so hints revealed at the same time are each paid for
+	used, err := s.attemptRepo.RevealHint(ctx, attempt.ID, key, len(hints))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w, or the attempt was closed", ErrNoHintsLeft)
+		}
+		return nil, err
+	}
+	return &HintReveal{
+		AttemptID: attempt.ID,
+		Question:  position,
+		Hint:      hints[used-1],
+		HintsUsed: used,
+		HintsLeft: len(hints) - used,
+	}, nil
+}
+
+// runningAttempt returns one of the user's attempts that is still open at now.
+func (s *AttemptService) runningAttempt(ctx context.Context, userID primitive.ObjectID, attemptID primitive.ObjectID, now time.Time) (*model.Attempt, error) {
+	attempt, err := s.GetAttempt(ctx, userID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.Status != model.AttemptInProgress {
+		return nil, ErrAttemptClosed
+	}
+	if attempt.ExpiresAt != nil && now.After(attempt.ExpiresAt.Add(submissionGrace)) {
+		return nil, ErrAttemptExpired
+	}
+	return attempt, nil
+}
+
+// attemptQuestions returns the questions the attempt was started on, in quiz order.
+func (s *AttemptService) attemptQuestions(ctx context.Context, attempt *model.Attempt) ([]model.Question, error) {
+	quiz, err := s.quizRepo.FindByID(ctx, attempt.QuizID)
+	if err != nil {
+		return nil, err
+	}
+	played, err := playedQuiz(ctx, s.versionRepo, quiz, attempt)
+	if err != nil {
+		return nil, err
+	}
+	return played.Questions, nil
+}
+
+// GetAttempt returns one of the user's attempts with its per-question results.
+func (s *AttemptService) GetAttempt(ctx context.Context, userID primitive.ObjectID, attemptID primitive.ObjectID) (*model.Attempt, error) {
+	attempt, err := s.attemptRepo.FindByID(ctx, attemptID)
+	if err != nil || attempt.UserID != userID {
+		return nil, ErrAttemptNotFound
+	}
+	return attempt, nil
+}
+
+// AttemptReview is the post-submission review of an attempt: every question in
+// the order the user saw it, with their answer, the correct answer and its explanation.
+type AttemptReview struct {
+	AttemptID   primitive.ObjectID     `json:"attempt_id"`
+	QuizID      primitive.ObjectID     `json:"quiz_id"`
+	QuizTitle   string                 `json:"quiz_title"`
+	Score       int                    `json:"score"`
+	Correct     int                    `json:"correct"`
+	Percentage  int                    `json:"percentage"`
+	SubmittedAt *time.Time             `json:"submitted_at,omitempty"`
+	Questions   []model.QuestionResult `json:"questions"`
+}
+
+// ReviewAttempt returns the review of one of the user's attempts. It is refused
+// while the attempt is open, so answers and explanations can't leak mid-quiz.
+func (s *AttemptService) ReviewAttempt(ctx context.Context, userID primitive.ObjectID, attemptID primitive.ObjectID) (*AttemptReview, error) {
+	attempt, err := s.GetAttempt(ctx, userID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.Status != model.AttemptSubmitted {
+		return nil, ErrAttemptNotSubmitted
+	}
+
+	questions := attempt.Results
+	if questions == nil {
+		questions = []model.QuestionResult{}
+	}
+	return &AttemptReview{
+		AttemptID:   attempt.ID,
+		QuizID:      attempt.QuizID,
+		QuizTitle:   attempt.QuizTitle,
+		Score:       attempt.Score,
+		Correct:     attempt.Correct,
+		Percentage:  attempt.Percentage,
+		SubmittedAt: attempt.EndedAt,
+		Questions:   questions,
+	}, nil
+}
+
+// userQuizStat returns the user's stats for the quiz and whether they are tracked.
+// Quizzes completed before stats were tracked count as one attempt with unknown values.
+func userQuizStat(user *model.User, quizID primitive.ObjectID) (model.QuizStat, bool) {
+	if stat, ok := user.QuizStats[quizID.Hex()]; ok {
+		return stat, true
+	}
+	if slices.Contains(user.CompletedQuizIDs, quizID) {
+		return model.QuizStat{Attempts: 1}, false
+	}
+	return model.QuizStat{}, true
+}
+
+// checkRetakePolicy enforces the quiz's attempt limit and cooldown for the user.
+func checkRetakePolicy(quiz *model.Quiz, user *model.User, now time.Time) error {
+	stat, _ := userQuizStat(user, quiz.ID)
+	if stat.Attempts == 0 {
+		return nil
+	}
+
+	limit := quiz.Retake.AttemptLimit()
+	if limit == 1 {
+		return ErrQuizAlreadyAttempted
+	}
+	if limit > 0 && stat.Attempts >= limit {
+		return ErrRetakeLimitReached
+	}
+	if quiz.Retake.Cooldown > 0 && !stat.LastAttemptAt.IsZero() {
+		availableAt := stat.LastAttemptAt.Add(time.Duration(quiz.Retake.Cooldown) * time.Second)
+		if now.Before(availableAt) {
+			return fmt.Errorf("%w: try again in %s", ErrRetakeCooldown, availableAt.Sub(now).Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// openAttempt loads an attempt and checks that it can still be submitted by the user for the quiz.
+// Late attempts are left to the attempt sweeper, which submits their saved answers.
+func openAttempt(ctx context.Context, attemptRepo repo.AttemptRepo, attemptID, userID, quizID primitive.ObjectID) (*model.Attempt, error) {
+	attempt, err := attemptRepo.FindByID(ctx, attemptID)
+	if err != nil || attempt.UserID != userID || attempt.QuizID != quizID {
+		return nil, ErrAttemptNotFound
+	}
+	if attempt.Status != model.AttemptInProgress {
+		return nil, ErrAttemptClosed
+	}
+
+	now := time.Now()
+	if attempt.ExpiresAt != nil && now.After(attempt.ExpiresAt.Add(submissionGrace)) {
+		return nil, ErrAttemptExpired
+	}
+	return attempt, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// sweepBatch caps how many abandoned attempts one sweep handles.
+	sweepBatch = 100
+	// maxSweepFailures is how many sweeps may fail to submit an attempt before it
+	// is closed as expired without grading, so it stops holding up the others.
+	maxSweepFailures = 5
+	// maxIdleAttempt is how long an untimed attempt stays open without answers
+	// being saved.
+	maxIdleAttempt = 7 * 24 * time.Hour
+)
+
+// CloseExpiredAttempts handles timed attempts abandoned past their deadline, and
+// untimed ones left idle for maxIdleAttempt. Those with saved answers are graded as
+// of the deadline, or the last save, and submitted like the player would have; the
+// others, those the retake policy no longer counts and those that keep failing are
+// closed as expired. It returns how many attempts were submitted.
+func (s *QuizService) CloseExpiredAttempts(ctx context.Context) (int, error) {
+	now := time.Now()
+	expired, err := s.attemptRepo.FindExpired(ctx, now.Add(-submissionGrace), now.Add(-maxIdleAttempt), sweepBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	submitted, ranked := 0, false
+	for i := range expired {
+		attempt := &expired[i]
+		err := s.autoSubmit(ctx, attempt)
+		switch {
+		case err == nil:
+			submitted++
+			ranked = ranked || !attempt.Practice
+			continue
+		case errors.Is(err, errNothingSaved), errors.Is(err, ErrAttemptClosed), errors.Is(err, ErrQuizAlreadyAttempted),
+			errors.Is(err, ErrRetakeLimitReached), errors.Is(err, ErrRetakeCooldown), errors.Is(err, mongo.ErrNoDocuments):
+		default:
+			failures, ferr := s.attemptRepo.RecordSweepFailure(ctx, attempt.ID)
+			if ferr != nil && !errors.Is(ferr, mongo.ErrNoDocuments) {
+				return submitted, ferr
+			}
+			if ferr == nil && failures < maxSweepFailures {
+				// left open for the next sweep
+				log.Printf("Error auto-submitting attempt %s: %v", attempt.ID.Hex(), err)
+				continue
+			}
+			log.Printf("Closing attempt %s without grading it: %v", attempt.ID.Hex(), err)
+		}
+
+		// Guarded on the in-progress status, so a submission racing the sweep wins
+		if err := s.attemptRepo.Close(ctx, attempt.ID, model.AttemptExpired, attempt.AbandonedAt()); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return submitted, err
+		}
+	}
+
+	if ranked {
+		s.leaderboard.BroadcastUpdate()
+	}
+	return submitted, nil
+}
+
+var errNothingSaved = errors.New("no answers saved")
+
+// autoSubmit grades the saved answers of an abandoned attempt as of the time it
+// was abandoned and records it.
+func (s *QuizService) autoSubmit(ctx context.Context, attempt *model.Attempt) error {
+	if len(attempt.Answers) == 0 {
+		return errNothingSaved
+	}
+	quiz, err := s.quizRepo.FindByID(ctx, attempt.QuizID)
+	if err != nil {
+		return err
+	}
+	if err := s.gradeAttempt(ctx, quiz, attempt, nil, attempt.AbandonedAt()); err != nil {
+		return err
+	}
+	attempt.AutoSubmitted = true
+	return s.recordAttempt(ctx, quiz, attempt)
+}
+
+// RunAttemptSweeper closes abandoned attempts every interval until ctx is done.
+func (s *QuizService) RunAttemptSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.CloseExpiredAttempts(ctx); err != nil {
+				log.Printf("Error closing expired attempts: %v", err)
+			} else if n > 0 {
+				log.Printf("Auto-submitted %d abandoned attempts", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCloseExpiredAttempts(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	quiz.Status = model.QuizPublished
+	quiz.Retake = model.RetakePolicy{Mode: model.RetakeUnlimited}
+	player := primitive.NewObjectID()
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+	answered := map[string]any{"0": 0}
+
+	tests := []struct {
+		name          string
+		attempt       model.Attempt
+		want          model.AttemptStatus
+		wantEndedAt   *time.Time
+		autoSubmitted bool
+	}{
+		{"timed with saved answers", model.Attempt{StartedAt: *ago(time.Hour), ExpiresAt: ago(time.Minute), Answers: answered, SavedAt: ago(2 * time.Minute)},
+			model.AttemptSubmitted, ago(time.Minute), true},
+		{"timed without answers", model.Attempt{StartedAt: *ago(time.Hour), ExpiresAt: ago(time.Minute)},
+			model.AttemptExpired, ago(time.Minute), false},
+		{"timed still running", model.Attempt{StartedAt: *ago(time.Minute), ExpiresAt: ptr(now.Add(time.Minute))},
+			model.AttemptInProgress, nil, false},
+		{"untimed idle with saved answers", model.Attempt{StartedAt: *ago(10 * 24 * time.Hour), Answers: answered, SavedAt: ago(8 * 24 * time.Hour)},
+			model.AttemptSubmitted, ago(8 * 24 * time.Hour), true},
+		{"untimed idle without answers", model.Attempt{StartedAt: *ago(8 * 24 * time.Hour)},
+			model.AttemptExpired, ago(8 * 24 * time.Hour), false},
+		{"untimed saved recently", model.Attempt{StartedAt: *ago(10 * 24 * time.Hour), Answers: answered, SavedAt: ago(24 * time.Hour)},
+			model.AttemptInProgress, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := &fakeAttemptRepo{}
+			attempt := tt.attempt
+			attempt.QuizID, attempt.UserID, attempt.QuizVersion, attempt.Status = quiz.ID, player, quiz.Version, model.AttemptInProgress
+			if err := attempts.Create(context.Background(), &attempt); err != nil {
+				t.Fatal(err)
+			}
+			users := &fakeUserRepo{users: map[primitive.ObjectID]model.User{player: {UserId: player}}}
+			s := NewQuizService(&fakeQuizRepo{quiz: quiz}, users, attempts, &fakeVersionRepo{}, nil, nil, nil, NewLeaderboardService(users, noopBroadcaster{}), nil)
+
+			if _, err := s.CloseExpiredAttempts(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			stored, _ := attempts.FindByID(context.Background(), attempt.ID)
+			if stored.Status != tt.want || stored.AutoSubmitted != tt.autoSubmitted {
+				t.Errorf("attempt is %s, auto submitted %v, want %s, %v", stored.Status, stored.AutoSubmitted, tt.want, tt.autoSubmitted)
+			}
+			if tt.wantEndedAt != nil && (stored.EndedAt == nil || !stored.EndedAt.Equal(*tt.wantEndedAt)) {
+				t.Errorf("attempt ended at %v, want %v", stored.EndedAt, *tt.wantEndedAt)
+			}
+			if counted := users.users[player].QuizStats[quiz.ID.Hex()].Attempts; (tt.want == model.AttemptSubmitted) != (counted == 1) {
+				t.Errorf("stats count %d attempts after the sweep", counted)
+			}
+		})
+	}
+}
+
+func TestCloseExpiredAttemptsGivesUpOnFailingAttempts(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	quiz.Status = model.QuizPublished
+	player := primitive.NewObjectID()
+	expiry := time.Now().Add(-time.Hour)
+
+	attempts := &fakeAttemptRepo{}
+	broken := model.Attempt{QuizID: quiz.ID, UserID: player, QuizVersion: quiz.Version, Status: model.AttemptInProgress, StartedAt: expiry.Add(-time.Hour), ExpiresAt: &expiry, Answers: map[string]any{"0": 0}}
+	if err := attempts.Create(context.Background(), &broken); err != nil {
+		t.Fatal(err)
+	}
+	users := &fakeUserRepo{users: map[primitive.ObjectID]model.User{player: {UserId: player}}}
+	// Every stats update loses to another one, so the attempt can never be counted
+	users.beforeUpdate = func(r *fakeUserRepo) { r.bumpScore(player, 1) }
+	s := NewQuizService(&fakeQuizRepo{quiz: quiz}, users, attempts, &fakeVersionRepo{}, nil, nil, nil, NewLeaderboardService(users, noopBroadcaster{}), nil)
+
+	for sweep := 1; sweep <= maxSweepFailures; sweep++ {
+		if _, err := s.CloseExpiredAttempts(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		stored, _ := attempts.FindByID(context.Background(), broken.ID)
+		want := model.AttemptInProgress
+		if sweep == maxSweepFailures {
+			want = model.AttemptExpired
+		}
+		if stored.Status != want {
+			t.Fatalf("after sweep %d the attempt is %s, want %s", sweep, stored.Status, want)
+		}
+	}
+}
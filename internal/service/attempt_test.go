@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStartAttemptHonorsRole(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	quiz.Status = model.QuizPublished
+	quiz.Visibility = model.VisibilityPrivate
+	quiz.AccessTier = model.TierPro
+
+	tests := []struct {
+		role    model.Role
+		wantErr error
+	}{
+		{model.RolePlayer, ErrQuizNotFound}, // no share link
+		{model.RoleCreator, ErrQuizNotFound},
+		{model.RoleModerator, nil},
+		{model.RoleAdmin, nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			s := NewAttemptService(&fakeAttemptRepo{}, &fakeQuizRepo{quiz: quiz}, nil, nil, nil, &ShareService{}, noSubscriptions{})
+			_, err := s.StartAttempt(context.Background(), NewActor(primitive.NewObjectID(), string(tt.role)), quiz.ID, "", true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStartAttemptChecksPlan(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	quiz.Status = model.QuizPublished
+	quiz.AccessTier = model.TierPro
+	s := NewAttemptService(&fakeAttemptRepo{}, &fakeQuizRepo{quiz: quiz}, nil, nil, nil, &ShareService{}, noSubscriptions{})
+
+	_, err := s.StartAttempt(context.Background(), NewActor(primitive.NewObjectID(), string(model.RolePlayer)), quiz.ID, "", true)
+	if !errors.Is(err, ErrUpgradeRequired) {
+		t.Errorf("player on the free plan got %v, want ErrUpgradeRequired", err)
+	}
+	if _, err := s.StartAttempt(context.Background(), NewActor(primitive.NewObjectID(), string(model.RoleModerator)), quiz.ID, "", true); err != nil {
+		t.Errorf("moderator got %v, want access", err)
+	}
+}
+
+// recordingSetup stores two open attempts of quiz by a new player and returns
+// the service recording them.
+func recordingSetup(t *testing.T, quiz model.Quiz) (*QuizService, *fakeAttemptRepo, *fakeUserRepo, [2]model.Attempt) {
+	t.Helper()
+	player := primitive.NewObjectID()
+	attempts := &fakeAttemptRepo{}
+	var open [2]model.Attempt
+	for i := range open {
+		open[i] = model.Attempt{QuizID: quiz.ID, UserID: player, Status: model.AttemptInProgress, StartedAt: time.Now()}
+		if err := attempts.Create(context.Background(), &open[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	users := &fakeUserRepo{users: map[primitive.ObjectID]model.User{player: {UserId: player}}}
+	s := NewQuizService(&fakeQuizRepo{quiz: quiz}, users, attempts, nil, nil, nil, nil, nil, nil)
+	return s, attempts, users, open
+}
+
+// graded returns a copy of the attempt as a submission grades it.
+func graded(attempt model.Attempt, score int) *model.Attempt {
+	now := time.Now()
+	attempt.EndedAt, attempt.Score, attempt.Percentage = &now, score, 100
+	return &attempt
+}
+
+func TestRecordAttemptCountsEachAttemptOnce(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	quiz.Retake = model.RetakePolicy{Mode: model.RetakeUnlimited, Counted: model.CountLatest}
+	s, attempts, users, open := recordingSetup(t, quiz)
+	ctx := context.Background()
+
+	// A second submission of A loads it while it is still in progress, and only
+	// records it after A and then B were counted
+	late := graded(open[0], 10)
+	if err := s.recordAttempt(ctx, &quiz, graded(open[0], 10)); err != nil {
+		t.Fatalf("submission of A: %v", err)
+	}
+	if err := s.recordAttempt(ctx, &quiz, graded(open[1], 10)); err != nil {
+		t.Fatalf("submission of B: %v", err)
+	}
+	if err := s.recordAttempt(ctx, &quiz, late); !errors.Is(err, ErrAttemptClosed) {
+		t.Errorf("late submission of A got %v, want ErrAttemptClosed", err)
+	}
+
+	user := users.users[open[0].UserID]
+	if stat := user.QuizStats[quiz.ID.Hex()]; stat.Attempts != 2 || stat.LastAttemptID != open[1].ID {
+		t.Errorf("quiz stats count %d attempts, last %s, want 2 ending with B", stat.Attempts, stat.LastAttemptID.Hex())
+	}
+	if user.Score != 10 || user.Streak != 2 {
+		t.Errorf("score %d and streak %d, want 10 and 2", user.Score, user.Streak)
+	}
+	for _, a := range attempts.attempts {
+		if a.Status != model.AttemptSubmitted {
+			t.Errorf("attempt %s is %s, want submitted", a.ID.Hex(), a.Status)
+		}
+	}
+}
+
+func TestRecordAttemptReleasesUncountedAttempts(t *testing.T) {
+	tests := []struct {
+		name    string
+		retake  model.RetakeMode
+		prepare func(users *fakeUserRepo, player primitive.ObjectID, quizID primitive.ObjectID)
+		wantErr error
+	}{
+		{"retake policy", model.RetakeSingle, func(users *fakeUserRepo, player, quizID primitive.ObjectID) {
+			user := users.users[player]
+			user.CompletedQuizIDs = []primitive.ObjectID{quizID}
+			users.users[player] = user
+		}, ErrQuizAlreadyAttempted},
+		{"stats conflict", model.RetakeUnlimited, func(users *fakeUserRepo, player, _ primitive.ObjectID) {
+			users.beforeUpdate = func(r *fakeUserRepo) { r.bumpScore(player, 1) }
+		}, ErrStatsConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz := versionedQuiz(primitive.NewObjectID())
+			quiz.Retake = model.RetakePolicy{Mode: tt.retake}
+			s, attempts, users, open := recordingSetup(t, quiz)
+			tt.prepare(users, open[0].UserID, quiz.ID)
+
+			if err := s.recordAttempt(context.Background(), &quiz, graded(open[0], 10)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := attempts.FindByID(context.Background(), open[0].ID)
+			if stored.Status != model.AttemptInProgress {
+				t.Errorf("attempt is %s, want it back in progress", stored.Status)
+			}
+		})
+	}
+}
+
+func TestSaveAnswers(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	player := primitive.NewObjectID()
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		attempt model.Attempt
+		saves   []map[string]any
+		user    primitive.ObjectID
+		want    map[string]any
+		wantErr error
+	}{
+		{"answers are merged and null clears one", model.Attempt{Status: model.AttemptInProgress},
+			[]map[string]any{{"0": 1, "1": 0}, {"0": nil, "2": 1}}, player, map[string]any{"1": 0, "2": 1}, nil},
+		{"position outside the attempt", model.Attempt{Status: model.AttemptInProgress, QuestionCount: 2},
+			[]map[string]any{{"2": 0}}, player, nil, ErrInvalidAnswers},
+		{"nothing to save", model.Attempt{Status: model.AttemptInProgress},
+			[]map[string]any{{}}, player, nil, ErrInvalidAnswers},
+		{"submitted attempt", model.Attempt{Status: model.AttemptSubmitted},
+			[]map[string]any{{"0": 1}}, player, nil, ErrAttemptClosed},
+		{"past the deadline", model.Attempt{Status: model.AttemptInProgress, ExpiresAt: &past},
+			[]map[string]any{{"0": 1}}, player, nil, ErrAttemptExpired},
+		{"another user's attempt", model.Attempt{Status: model.AttemptInProgress},
+			[]map[string]any{{"0": 1}}, primitive.NewObjectID(), nil, ErrAttemptNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := &fakeAttemptRepo{}
+			attempt := tt.attempt
+			attempt.QuizID, attempt.UserID = quiz.ID, player
+			if err := attempts.Create(context.Background(), &attempt); err != nil {
+				t.Fatal(err)
+			}
+			s := NewAttemptService(attempts, &fakeQuizRepo{quiz: quiz}, nil, nil, nil, nil, nil)
+
+			var err error
+			for _, answers := range tt.saves {
+				if _, err = s.SaveAnswers(context.Background(), tt.user, attempt.ID, answers); err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := attempts.FindByID(context.Background(), attempt.ID)
+			if !reflect.DeepEqual(stored.Answers, tt.want) {
+				t.Errorf("saved answers are %v, want %v", stored.Answers, tt.want)
+			}
+		})
+	}
+}
+
+func TestResumeAttempt(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	player := primitive.NewObjectID()
+	expires := time.Now().Add(90 * time.Second)
+	attempts := &fakeAttemptRepo{}
+	attempt := model.Attempt{QuizID: quiz.ID, UserID: player, QuizVersion: quiz.Version, Status: model.AttemptInProgress, StartedAt: time.Now(), ExpiresAt: &expires}
+	if err := attempts.Create(context.Background(), &attempt); err != nil {
+		t.Fatal(err)
+	}
+	s := NewAttemptService(attempts, &fakeQuizRepo{quiz: quiz}, nil, nil, nil, nil, nil)
+	if _, err := s.SaveAnswers(context.Background(), player, attempt.ID, map[string]any{"0": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := s.ResumeAttempt(context.Background(), player, attempt.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Remaining == nil || *resumed.Remaining < 85 || *resumed.Remaining > 90 {
+		t.Errorf("remaining is %v, want about 90 seconds", resumed.Remaining)
+	}
+	if len(resumed.Served) != len(quiz.Questions) || resumed.Answers["0"] != 1 {
+		t.Errorf("served %d questions with answers %v, want %d with the saved answer", len(resumed.Served), resumed.Answers, len(quiz.Questions))
+	}
+}
+
+func TestRevealHint(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	quiz.Questions = []model.Question{
+		{Text: "Capital of France?", Options: []string{"Paris", "Lyon", "Nice"}, Answer: 0,
+			Hints: []model.Hint{{Type: model.HintEliminate, Option: 2}, {Type: model.HintClue, Text: "On the Seine"}}},
+		{Text: "Capital of Spain?", Options: []string{"Madrid", "Seville"}, Answer: 0},
+	}
+	player := primitive.NewObjectID()
+	attempts := &fakeAttemptRepo{}
+	// The questions are shown swapped, and Nice is shown first
+	attempt := model.Attempt{QuizID: quiz.ID, UserID: player, QuizVersion: quiz.Version, Status: model.AttemptInProgress, StartedAt: time.Now(),
+		QuestionOrder: []int{1, 0}, OptionOrders: [][]int{{2, 0, 1}, nil}}
+	if err := attempts.Create(context.Background(), &attempt); err != nil {
+		t.Fatal(err)
+	}
+	s := NewAttemptService(attempts, &fakeQuizRepo{quiz: quiz}, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	first, err := s.RevealHint(ctx, player, attempt.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (model.Hint{Type: model.HintEliminate, Option: 0}); first.Hint != want || first.HintsUsed != 1 || first.HintsLeft != 1 {
+		t.Errorf("first reveal is %+v, want %+v with 1 used and 1 left", first, want)
+	}
+	second, err := s.RevealHint(ctx, player, attempt.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Hint.Type != model.HintClue || second.HintsUsed != 2 || second.HintsLeft != 0 {
+		t.Errorf("second reveal is %+v, want the clue with none left", second)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		position int
+		wantErr  error
+	}{
+		{"hints ran out", 1, ErrNoHintsLeft},
+		{"question without hints", 0, ErrNoHintsLeft},
+		{"position outside the attempt", 2, ErrQuestionNotFound},
+	} {
+		if _, err := s.RevealHint(ctx, player, attempt.ID, tt.position); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// The hints count against the question they were revealed on once graded
+	stored, _ := attempts.FindByID(ctx, attempt.ID)
+	if got := canonicalHints(stored, quiz.Questions); !reflect.DeepEqual(got, map[int]int{0: 2}) {
+		t.Errorf("hints by quiz question are %v, want two on question 0", got)
+	}
+}
+
+func TestRevealHintOnClosedAttempt(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	quiz.Questions[0].Hints = []model.Hint{{Type: model.HintClue, Text: "City of light"}}
+	player := primitive.NewObjectID()
+	attempts := &fakeAttemptRepo{}
+	attempt := model.Attempt{QuizID: quiz.ID, UserID: player, QuizVersion: quiz.Version, Status: model.AttemptSubmitted}
+	if err := attempts.Create(context.Background(), &attempt); err != nil {
+		t.Fatal(err)
+	}
+	s := NewAttemptService(attempts, &fakeQuizRepo{quiz: quiz}, nil, nil, nil, nil, nil)
+
+	if _, err := s.RevealHint(context.Background(), player, attempt.ID, 0); !errors.Is(err, ErrAttemptClosed) {
+		t.Errorf("got %v, want ErrAttemptClosed", err)
+	}
+}
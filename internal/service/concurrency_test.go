@@ -0,0 +1,278 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/sachinggsingh/quiz/config"
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/repo"
+	"github.com/sachinggsingh/quiz/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	quizCount    = 8  // different quizzes submitted at the same time, below the stats retry budget
+	duplicates   = 10 // submissions of the same attempt at the same time
+	quizPoints   = 10
+	testDBSuffix = "_concurrency_test"
+)
+
+type noopBroadcaster struct{}
+
+func (noopBroadcaster) BroadcastLeaderboardUpdate([]service.LeaderboardEntry) {}
+
+type env struct {
+	quizRepo    repo.QuizRepo
+	userRepo    repo.UserRepo
+	attemptRepo repo.AttemptRepo
+	quizzes     *service.QuizService
+	attempts    *service.AttemptService
+	player      service.Actor
+	// quizzesWith builds a quiz service reading attempts from another repo
+	quizzesWith func(attempts repo.AttemptRepo) *service.QuizService
+}
+
+// staleAttempts serves a copy of an attempt taken earlier, like a submission that
+// loaded the attempt and got delayed.
+type staleAttempts struct {
+	repo.AttemptRepo
+	attempt model.Attempt
+}
+
+func (r staleAttempts) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Attempt, error) {
+	if id == r.attempt.ID {
+		attempt := r.attempt
+		return &attempt, nil
+	}
+	return r.AttemptRepo.FindByID(ctx, id)
+}
+
+// TestConcurrentSubmissions hammers the submission path against the MongoDB in
+// MONGO_URI and checks that the user's stats come out exactly right. It runs on
+// a throwaway database named after DB_NAME, dropped when it is done.
+func TestConcurrentSubmissions(t *testing.T) {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set")
+	}
+	dbName := os.Getenv("DB_NAME")
+	if dbName == "" {
+		dbName = "quiz"
+	}
+	db, err := config.ConnectDB(uri, dbName+testDBSuffix)
+	if err != nil {
+		t.Fatalf("Failed to connect to DB: %v", err)
+	}
+	ctx := context.Background()
+	t.Cleanup(func() {
+		if err := db.DB.Drop(ctx); err != nil {
+			t.Logf("Failed to drop the test database: %v", err)
+		}
+		db.Client.Disconnect(ctx)
+	})
+
+	e := newEnv(db.DB)
+	user := &model.User{Name: "Concurrency", Email: "concurrency@example.com"}
+	if err := e.userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	e.player = service.NewActor(user.UserId, string(model.RolePlayer))
+
+	t.Run("concurrent quizzes", e.concurrentQuizzes)
+	t.Run("double submit", e.doubleSubmit)
+	t.Run("concurrent attempts of a single-attempt quiz", e.concurrentAttemptsOfSingleQuiz)
+	t.Run("stale resubmission", e.staleResubmission)
+
+	final, err := e.userRepo.FindByID(ctx, user.UserId)
+	if err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	// Every quiz of the four scenarios counts exactly once, and every counted
+	// attempt, two of them for the stale resubmission, adds to the streak
+	quizzes, counted := quizCount+3, quizCount+4
+	if want := quizzes * quizPoints; final.Score != want {
+		t.Errorf("score is %d, want %d", final.Score, want)
+	}
+	if len(final.CompletedQuizIDs) != quizzes {
+		t.Errorf("%d completed quizzes, want %d", len(final.CompletedQuizIDs), quizzes)
+	}
+	if final.AverageScore != 100 {
+		t.Errorf("average score is %.2f, want 100", final.AverageScore)
+	}
+	if final.Streak != counted {
+		t.Errorf("streak is %d, want %d", final.Streak, counted)
+	}
+}
+
+func newEnv(db *mongo.Database) *env {
+	quizRepo := repo.NewQuizRepo(db)
+	userRepo := repo.NewUserRepo(db)
+	attemptRepo := repo.NewAttemptRepo(db)
+	versionRepo := repo.NewQuizVersionRepo(db)
+	questionBank := service.NewQuestionBankService(repo.NewQuestionBankRepo(db))
+	shares := service.NewShareService(repo.NewShareLinkRepo(db), quizRepo)
+	leaderboard := service.NewLeaderboardService(userRepo, noopBroadcaster{})
+	quizzesWith := func(attempts repo.AttemptRepo) *service.QuizService {
+		return service.NewQuizService(quizRepo, userRepo, attempts, versionRepo, questionBank, shares, nil, leaderboard, nil)
+	}
+	return &env{
+		quizRepo:    quizRepo,
+		userRepo:    userRepo,
+		attemptRepo: attemptRepo,
+		quizzes:     quizzesWith(attemptRepo),
+		attempts:    service.NewAttemptService(attemptRepo, quizRepo, userRepo, versionRepo, questionBank, shares, nil),
+		quizzesWith: quizzesWith,
+	}
+}
+
+// concurrentQuizzes submits one attempt of many quizzes at once. Every one of them
+// must land in the stats.
+func (e *env) concurrentQuizzes(t *testing.T) {
+	quizzes := make([]*model.Quiz, quizCount)
+	attempts := make([]*model.Attempt, quizCount)
+	for i := range quizzes {
+		quizzes[i] = e.createQuiz(t, fmt.Sprintf("Concurrent %d", i), model.RetakePolicy{Mode: model.RetakeSingle})
+		attempts[i] = e.start(t, quizzes[i])
+	}
+
+	errs := parallel(quizCount, func(i int) error {
+		return e.submit(quizzes[i], attempts[i])
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("submission of quiz %d failed: %v", i, err)
+		}
+	}
+}
+
+// doubleSubmit sends the same attempt many times at once. Exactly one submission
+// may be graded.
+func (e *env) doubleSubmit(t *testing.T) {
+	quiz := e.createQuiz(t, "Double submit", model.RetakePolicy{Mode: model.RetakeUnlimited})
+	attempt := e.start(t, quiz)
+
+	errs := parallel(duplicates, func(int) error {
+		return e.submit(quiz, attempt)
+	})
+	if ok := countResults(t, errs, service.ErrAttemptClosed); ok != 1 {
+		t.Errorf("%d submissions of the same attempt succeeded, want 1", ok)
+	}
+}
+
+// concurrentAttemptsOfSingleQuiz opens two attempts of a single-attempt quiz and
+// submits both at once. Only one of them may count.
+func (e *env) concurrentAttemptsOfSingleQuiz(t *testing.T) {
+	quiz := e.createQuiz(t, "Single attempt", model.RetakePolicy{Mode: model.RetakeSingle})
+	attempts := []*model.Attempt{e.start(t, quiz), e.start(t, quiz)}
+
+	errs := parallel(len(attempts), func(i int) error {
+		return e.submit(quiz, attempts[i])
+	})
+	if ok := countResults(t, errs, service.ErrQuizAlreadyAttempted); ok != 1 {
+		t.Errorf("%d attempts of a single-attempt quiz succeeded, want 1", ok)
+	}
+}
+
+// staleResubmission submits attempt A, then attempt B of the same quiz, then A
+// again through a submission that loaded A while it was still in progress. The
+// late submission must not count A a second time.
+func (e *env) staleResubmission(t *testing.T) {
+	ctx := context.Background()
+	quiz := e.createQuiz(t, "Stale resubmission", model.RetakePolicy{Mode: model.RetakeUnlimited, Counted: model.CountLatest})
+	a, b := e.start(t, quiz), e.start(t, quiz)
+	loaded, err := e.attemptRepo.FindByID(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("Failed to load attempt: %v", err)
+	}
+
+	if err := e.submit(quiz, a); err != nil {
+		t.Fatalf("First submission of A failed: %v", err)
+	}
+	if err := e.submit(quiz, b); err != nil {
+		t.Fatalf("Submission of B failed: %v", err)
+	}
+	late := e.quizzesWith(staleAttempts{AttemptRepo: e.attemptRepo, attempt: *loaded})
+	if _, err := late.SubmitQuiz(ctx, e.player, quiz.ID, a.ID, map[string]any{"0": 1}); !errors.Is(err, service.ErrAttemptClosed) {
+		t.Errorf("late submission of A got %v, want ErrAttemptClosed", err)
+	}
+
+	user, err := e.userRepo.FindByID(ctx, e.player.UserID)
+	if err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	if stat := user.QuizStats[quiz.ID.Hex()]; stat.Attempts != 2 || stat.LastAttemptID != b.ID {
+		t.Errorf("quiz stats count %d attempts, last %s, want 2 ending with B", stat.Attempts, stat.LastAttemptID.Hex())
+	}
+}
+
+func (e *env) createQuiz(t *testing.T, title string, retake model.RetakePolicy) *model.Quiz {
+	t.Helper()
+	quiz := &model.Quiz{
+		Title:     title,
+		Category:  "Testing",
+		Points:    quizPoints,
+		KeepOrder: true, // answers can be sent as authored
+		Retake:    retake,
+		Status:    model.QuizPublished,
+		Version:   1,
+		Questions: []model.Question{{Text: "2 + 2?", Options: []string{"3", "4"}, Answer: 1}},
+	}
+	if err := e.quizRepo.Create(context.Background(), quiz); err != nil {
+		t.Fatalf("Failed to create quiz: %v", err)
+	}
+	return quiz
+}
+
+func (e *env) start(t *testing.T, quiz *model.Quiz) *model.Attempt {
+	t.Helper()
+	attempt, err := e.attempts.StartAttempt(context.Background(), e.player, quiz.ID, "", false)
+	if err != nil {
+		t.Fatalf("Failed to start attempt of %q: %v", quiz.Title, err)
+	}
+	return attempt
+}
+
+func (e *env) submit(quiz *model.Quiz, attempt *model.Attempt) error {
+	_, err := e.quizzes.SubmitQuiz(context.Background(), e.player, quiz.ID, attempt.ID, map[string]any{"0": 1})
+	return err
+}
+
+// parallel runs n calls of fn released at the same moment and returns their errors.
+func parallel(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+// countResults returns how many calls succeeded and flags any failure other than rejected.
+func countResults(t *testing.T, errs []error, rejected error) int {
+	t.Helper()
+	ok := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case errors.Is(err, rejected):
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	return ok
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The fakes embed the repo interfaces, so a test calling a method they don't
+// implement panics instead of silently passing.
+
+// fakeQuizRepo holds a single quiz and applies version-guarded updates like Mongo would.
+type fakeQuizRepo struct {
+	repo.QuizRepo
+	mu   sync.Mutex
+	quiz model.Quiz
+}
+
+func (r *fakeQuizRepo) FindByID(_ context.Context, id primitive.ObjectID) (*model.Quiz, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.quiz.ID != id {
+		return nil, mongo.ErrNoDocuments
+	}
+	quiz := r.quiz
+	return &quiz, nil
+}
+
+func (r *fakeQuizRepo) Update(_ context.Context, quiz *model.Quiz, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.quiz.ID != quiz.ID || r.quiz.Version != version {
+		return mongo.ErrNoDocuments
+	}
+	r.quiz = *quiz
+	return nil
+}
+
+func (r *fakeQuizRepo) IncrementAttemptCount(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.quiz.ID != id {
+		return mongo.ErrNoDocuments
+	}
+	r.quiz.AttemptCount++
+	return nil
+}
+
+// fakeVersionRepo stores snapshots in memory with the unique (quiz_id, version) index.
+type fakeVersionRepo struct {
+	repo.QuizVersionRepo
+	mu       sync.Mutex
+	versions []model.QuizVersion
+}
+
+func (r *fakeVersionRepo) Create(_ context.Context, version *model.QuizVersion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.versions {
+		if v.QuizID == version.QuizID && v.Version == version.Version {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+		}
+	}
+	version.ID = primitive.NewObjectID()
+	r.versions = append(r.versions, *version)
+	return nil
+}
+
+func (r *fakeVersionRepo) FindOne(_ context.Context, quizID primitive.ObjectID, version int) (*model.QuizVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.versions {
+		if v.QuizID == quizID && v.Version == version {
+			return &v, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakeVersionRepo) Delete(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, v := range r.versions {
+		if v.ID == id {
+			r.versions = append(r.versions[:i], r.versions[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// fakeAttemptRepo keeps the attempts it is given and applies status-guarded
+// updates like Mongo would.
+type fakeAttemptRepo struct {
+	repo.AttemptRepo
+	mu       sync.Mutex
+	attempts []model.Attempt
+}
+
+func (r *fakeAttemptRepo) Create(_ context.Context, attempt *model.Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt.ID = primitive.NewObjectID()
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+func (r *fakeAttemptRepo) FindByID(_ context.Context, id primitive.ObjectID) (*model.Attempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.attempts {
+		if a.ID == id {
+			return &a, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// update applies change to the attempt if it is in the from status.
+func (r *fakeAttemptRepo) update(id primitive.ObjectID, from model.AttemptStatus, change func(a *model.Attempt)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.attempts {
+		if r.attempts[i].ID == id && r.attempts[i].Status == from {
+			change(&r.attempts[i])
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *fakeAttemptRepo) FindExpired(_ context.Context, before time.Time, idleBefore time.Time, limit int64) ([]model.Attempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired []model.Attempt
+	for _, a := range r.attempts {
+		if a.Status != model.AttemptInProgress || int64(len(expired)) == limit {
+			continue
+		}
+		if a.ExpiresAt != nil && a.ExpiresAt.Before(before) || a.ExpiresAt == nil && a.AbandonedAt().Before(idleBefore) {
+			expired = append(expired, a)
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeAttemptRepo) RecordSweepFailure(_ context.Context, id primitive.ObjectID) (int, error) {
+	failures := 0
+	err := r.update(id, model.AttemptInProgress, func(a *model.Attempt) {
+		a.SweepFailures++
+		failures = a.SweepFailures
+	})
+	return failures, err
+}
+
+func (r *fakeAttemptRepo) SaveAnswers(_ context.Context, id primitive.ObjectID, answers map[string]any, at time.Time) error {
+	return r.update(id, model.AttemptInProgress, func(a *model.Attempt) {
+		saved := map[string]any{}
+		maps.Copy(saved, a.Answers)
+		for key, answer := range answers {
+			if answer == nil {
+				delete(saved, key)
+			} else {
+				saved[key] = answer
+			}
+		}
+		a.Answers, a.SavedAt = saved, &at
+	})
+}
+
+func (r *fakeAttemptRepo) RevealHint(_ context.Context, id primitive.ObjectID, position string, available int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.attempts {
+		a := &r.attempts[i]
+		if a.ID != id || a.Status != model.AttemptInProgress || a.HintsUsed[position] >= available {
+			continue
+		}
+		if a.HintsUsed == nil {
+			a.HintsUsed = map[string]int{}
+		}
+		a.HintsUsed[position]++
+		return a.HintsUsed[position], nil
+	}
+	return 0, mongo.ErrNoDocuments
+}
+
+func (r *fakeAttemptRepo) Claim(_ context.Context, id primitive.ObjectID) error {
+	return r.update(id, model.AttemptInProgress, func(a *model.Attempt) { a.Status = model.AttemptGrading })
+}
+
+func (r *fakeAttemptRepo) Release(_ context.Context, id primitive.ObjectID) error {
+	return r.update(id, model.AttemptGrading, func(a *model.Attempt) { a.Status = model.AttemptInProgress })
+}
+
+func (r *fakeAttemptRepo) Submit(_ context.Context, attempt *model.Attempt) error {
+	return r.update(attempt.ID, model.AttemptGrading, func(a *model.Attempt) {
+		a.Status = model.AttemptSubmitted
+		a.EndedAt, a.Score, a.Percentage, a.Results, a.AutoSubmitted = attempt.EndedAt, attempt.Score, attempt.Percentage, attempt.Results, attempt.AutoSubmitted
+	})
+}
+
+func (r *fakeAttemptRepo) Close(_ context.Context, id primitive.ObjectID, status model.AttemptStatus, at time.Time) error {
+	return r.update(id, model.AttemptInProgress, func(a *model.Attempt) {
+		a.Status, a.EndedAt = status, &at
+	})
+}
+
+// noSubscriptions puts every user on the free plan.
+type noSubscriptions struct {
+	SubscriptionService
+}
+
+func (noSubscriptions) GetSubscription(context.Context, string) (*model.Subscription, error) {
+	return nil, nil
+}
+
+// fakeUserRepo holds users and applies version-guarded stats updates like Mongo
+// would. beforeUpdate, when set, runs before each stats update, so tests can
+// slip in a concurrent one.
+type fakeUserRepo struct {
+	repo.UserRepo
+	mu           sync.Mutex
+	users        map[primitive.ObjectID]model.User
+	updates      int
+	beforeUpdate func(r *fakeUserRepo)
+}
+
+func (r *fakeUserRepo) FindByID(_ context.Context, id primitive.ObjectID) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &user, nil
+}
+
+func (r *fakeUserRepo) UpdateStats(_ context.Context, user *model.User, version int) error {
+	if r.beforeUpdate != nil {
+		r.beforeUpdate(r)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates++
+	stored, ok := r.users[user.UserId]
+	if !ok || stored.StatsVersion != version {
+		return mongo.ErrNoDocuments
+	}
+	user.StatsVersion = version + 1
+	r.users[user.UserId] = *user
+	return nil
+}
+
+func (r *fakeUserRepo) GetTopUsers(context.Context, int64, int64) ([]model.User, int64, error) {
+	return nil, 0, nil
+}
+
+// bumpScore stores a stats update made behind the back of the one in progress.
+func (r *fakeUserRepo) bumpScore(id primitive.ObjectID, points int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := r.users[id]
+	user.Score += points
+	user.StatsVersion++
+	r.users[id] = user
+}
+
+type noopBroadcaster struct{}
+
+func (noopBroadcaster) BroadcastLeaderboardUpdate([]LeaderboardEntry) {}
+
+// fakeRatingRepo keeps one rating per user and quiz, and recomputes the counters
+// of the quiz held by quizzes like the aggregation does.
+type fakeRatingRepo struct {
+	repo.RatingRepo
+	mu      sync.Mutex
+	ratings []model.Rating
+	quizzes *fakeQuizRepo
+}
+
+func (r *fakeRatingRepo) Upsert(_ context.Context, rating *model.Rating) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.ratings {
+		if existing.QuizID == rating.QuizID && existing.UserID == rating.UserID {
+			rating.ID = existing.ID
+			r.ratings[i] = *rating
+			return nil
+		}
+	}
+	rating.ID = primitive.NewObjectID()
+	r.ratings = append(r.ratings, *rating)
+	return nil
+}
+
+func (r *fakeRatingRepo) RefreshQuizRating(_ context.Context, quizID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sum, count := 0, 0
+	for _, rating := range r.ratings {
+		if rating.QuizID == quizID {
+			sum += rating.Stars
+			count++
+		}
+	}
+	r.quizzes.mu.Lock()
+	defer r.quizzes.mu.Unlock()
+	r.quizzes.quiz.RatingCount = count
+	r.quizzes.quiz.AverageRating = 0
+	if count > 0 {
+		r.quizzes.quiz.AverageRating = float64(sum) / float64(count)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/quizio"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImportRow is the validation result of one imported question.
+type ImportRow struct {
+	Row    int                `json:"row"`
+	Type   model.QuestionType `json:"type,omitempty"`
+	Text   string             `json:"text,omitempty"`
+	Errors []string           `json:"errors,omitempty"`
+}
+
+// ImportReport describes what an import would create. Quiz is only set once
+// the quiz has been saved.
+type ImportReport struct {
+	Valid     bool        `json:"valid"`
+	DryRun    bool        `json:"dry_run"`
+	Questions int         `json:"questions"`
+	Errors    []string    `json:"errors,omitempty"` // quiz level
+	Rows      []ImportRow `json:"rows"`
+	Quiz      *model.Quiz `json:"quiz,omitempty"`
+}
+
+// ImportQuiz validates a decoded file row by row with the same checks as
+// CreateQuiz and, unless dryRun is set or a row is invalid, creates the quiz.
+func (s *QuizService) ImportQuiz(ctx context.Context, userID primitive.ObjectID, imp *quizio.Import, dryRun bool) (*ImportReport, error) {
+	report := &ImportReport{DryRun: dryRun, Rows: make([]ImportRow, 0, len(imp.Rows))}
+	quiz := imp.Quiz
+	quiz.Questions = make([]model.Question, 0, len(imp.Rows))
+
+	for _, row := range imp.Rows {
+		q := row.Question
+		result := ImportRow{Row: row.Line, Type: q.Kind(), Text: q.Text}
+		if row.Err != nil {
+			result.Type = q.Type
+			result.Errors = append(result.Errors, row.Err.Error())
+		} else {
+			for _, fe := range questionErrors(&q, "") {
+				result.Errors = append(result.Errors, fe.Error())
+			}
+		}
+		if len(result.Errors) == 0 {
+			quiz.Questions = append(quiz.Questions, q)
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	report.Questions = len(quiz.Questions)
+
+	if strings.TrimSpace(quiz.Title) == "" {
+		report.Errors = append(report.Errors, "title is required")
+	}
+	if len(imp.Rows) == 0 {
+		report.Errors = append(report.Errors, "no questions found")
+	}
+	if report.Questions == len(imp.Rows) {
+		var verr *ValidationError
+		if err := validateQuizContent(&quiz); errors.As(err, &verr) {
+			for _, fe := range verr.Errors {
+				report.Errors = append(report.Errors, fe.Error())
+			}
+		} else if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+	report.Valid = len(report.Errors) == 0 && report.Questions == len(imp.Rows)
+	if !report.Valid || dryRun {
+		return report, nil
+	}
+
+	created, err := s.CreateQuiz(ctx, userID, &quiz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save imported quiz: %w", err)
+	}
+	report.Quiz = created
+	return report, nil
+}
+
+// ExportQuiz returns a quiz with its answer keys for export. Only the people
+// managing the quiz can export it.
+func (s *QuizService) ExportQuiz(ctx context.Context, actor Actor, id primitive.ObjectID) (*model.Quiz, error) {
+	return s.findManagedQuiz(ctx, actor, id)
+}
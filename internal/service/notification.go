@@ -24,7 +24,7 @@ func (s *NotificationService) PublishQuizCreated(quiz model.Quiz) error {
 	}
 	data, err := json.Marshal(map[string]any{
 		"type": "NEW_QUIZ",
-		"data": quiz,
+		"data": quiz.Public(),
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// Who may perform a status transition.
+const (
+	byManager  = 1 << iota // the owner or an admin
+	byReviewer             // a moderator or an admin
+)
+
+// quizTransitions lists the allowed status changes. Creators submit drafts for
+// review, reviewers publish or schedule them or send them back.
+var quizTransitions = map[model.QuizStatus]map[model.QuizStatus]int{
+	model.QuizDraft: {
+		model.QuizInReview: byManager,
+	},
+	model.QuizInReview: {
+		model.QuizDraft:     byManager | byReviewer,
+		model.QuizPublished: byReviewer,
+		model.QuizScheduled: byReviewer,
+	},
+	model.QuizScheduled: {
+		model.QuizDraft:     byManager | byReviewer,
+		model.QuizPublished: byReviewer,
+	},
+	model.QuizPublished: {
+		model.QuizArchived: byManager | byReviewer,
+	},
+	model.QuizArchived: {
+		model.QuizDraft:     byManager,
+		model.QuizPublished: byReviewer,
+	},
+}
+
+func isReviewer(actor Actor) bool {
+	return actor.Role == model.RoleModerator || actor.Role == model.RoleAdmin
+}
+
+// canViewQuiz reports whether the actor may see a quiz that isn't public.
+func canViewQuiz(actor Actor, quiz *model.Quiz) bool {
+	return quiz.IsPublic() || canManageQuiz(actor, quiz) || isReviewer(actor)
+}
+
+// TransitionQuiz moves a quiz to another status. Publishing a quiz whose
+// publish_at is in the future schedules it instead, and scheduling one whose
+// publish_at has passed publishes it right away.
+func (s *QuizService) TransitionQuiz(ctx context.Context, actor Actor, id primitive.ObjectID, to model.QuizStatus, publishAt *time.Time) (*model.Quiz, error) {
+	quiz, err := s.findQuiz(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canViewQuiz(actor, quiz) {
+		return nil, ErrQuizNotFound
+	}
+
+	from := quiz.QuizStatus()
+	firstPublication := quiz.PublishedAt == nil
+	if publishAt == nil {
+		publishAt = quiz.PublishAt
+	}
+	now := time.Now()
+	switch to {
+	case model.QuizPublished:
+		if publishAt != nil && publishAt.After(now) {
+			to = model.QuizScheduled
+		}
+	case model.QuizScheduled:
+		if publishAt == nil {
+			return nil, fmt.Errorf("%w: publish_at is required to schedule a quiz", ErrInvalidTransition)
+		}
+		if !publishAt.After(now) {
+			to = model.QuizPublished
+		}
+	}
+
+	allowed, ok := quizTransitions[from][to]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+	if !(allowed&byManager != 0 && canManageQuiz(actor, quiz)) && !(allowed&byReviewer != 0 && isReviewer(actor)) {
+		return nil, ErrNotQuizOwner
+	}
+
+	if err := s.quizRepo.SetStatus(ctx, quiz.ID, from, to, publishAt); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrQuizConflict
+		}
+		return nil, err
+	}
+	quiz.Status = to
+	quiz.PublishAt = publishAt
+	if to == model.QuizPublished {
+		quiz.PublishedAt = &now
+		// Republished quizzes were announced already, and unlisted and private
+		// quizzes are only announced through their share links
+		if firstPublication && quiz.Listed() {
+			s.notificationService.PublishQuizCreated(*quiz)
+		}
+	}
+	return quiz, nil
+}
+
+// ListReviewQueue returns the quizzes waiting for a reviewer, newest first.
+func (s *QuizService) ListReviewQueue(ctx context.Context, page int64, limit int64) ([]model.Quiz, int64, error) {
+	filter := repo.QuizFilter{Statuses: []model.QuizStatus{model.QuizInReview}}
+	return s.quizRepo.FindPage(ctx, filter, repo.QuizSortNewest, page, limit, true)
+}
+
+// PublishDueQuizzes publishes the scheduled quizzes whose time has come and
+// returns how many were published.
+func (s *QuizService) PublishDueQuizzes(ctx context.Context) (int, error) {
+	due, err := s.quizRepo.FindDueScheduled(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for i := range due {
+		quiz := &due[i]
+		// Guarded on the scheduled status, so only one server instance publishes it
+		firstPublication := quiz.PublishedAt == nil
+		err := s.quizRepo.SetStatus(ctx, quiz.ID, model.QuizScheduled, model.QuizPublished, nil)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return published, err
+		}
+		now := time.Now()
+		quiz.Status = model.QuizPublished
+		quiz.PublishedAt = &now
+		if firstPublication && quiz.Listed() {
+			s.notificationService.PublishQuizCreated(*quiz)
+		}
+		published++
+	}
+	return published, nil
+}
+
+// RunPublishScheduler publishes due quizzes every interval until ctx is done.
+func (s *QuizService) RunPublishScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.PublishDueQuizzes(ctx); err != nil {
+				log.Printf("Error publishing scheduled quizzes: %v", err)
+			} else if n > 0 {
+				log.Printf("Published %d scheduled quizzes", n)
+			}
+		}
+	}
+}
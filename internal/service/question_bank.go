@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxRuleCount bounds how many questions a single rule can draw.
+const maxRuleCount = 100
+
+var (
+	ErrBankQuestionNotFound = errors.New("bank question not found")
+	ErrNotEnoughQuestions   = errors.New("not enough questions in the bank")
+)
+
+type QuestionBankService struct {
+	bankRepo repo.QuestionBankRepo
+}
+
+func NewQuestionBankService(bankRepo repo.QuestionBankRepo) *QuestionBankService {
+	return &QuestionBankService{
+		bankRepo: bankRepo,
+	}
+}
+
+// CreateQuestion adds a question to the user's bank, validated like quiz questions.
+func (s *QuestionBankService) CreateQuestion(ctx context.Context, userID primitive.ObjectID, question *model.BankQuestion) (*model.BankQuestion, error) {
+	if err := prepareBankQuestion(question); err != nil {
+		return nil, err
+	}
+	question.UserID = userID
+	if err := s.bankRepo.Create(ctx, question); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// ListQuestions returns a page of the actor's bank, filtered by tags and difficulty.
+func (s *QuestionBankService) ListQuestions(ctx context.Context, actor Actor, tags []string, difficulty string, page int64, limit int64) ([]model.BankQuestion, int64, error) {
+	filter := repo.BankFilter{UserID: actor.UserID, Tags: normalizeTags(tags), Difficulty: strings.TrimSpace(difficulty)}
+	return s.bankRepo.FindPage(ctx, filter, page, limit)
+}
+
+// GetQuestion returns a question of the actor's bank. Other users' questions
+// are reported as not found, admins excepted.
+func (s *QuestionBankService) GetQuestion(ctx context.Context, actor Actor, id primitive.ObjectID) (*model.BankQuestion, error) {
+	question, err := s.bankRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrBankQuestionNotFound
+		}
+		return nil, err
+	}
+	if question.UserID != actor.UserID && actor.Role != model.RoleAdmin {
+		return nil, ErrBankQuestionNotFound
+	}
+	return question, nil
+}
+
+// UpdateQuestion replaces a bank question. Attempts already served it keep the
+// version they were graded on.
+func (s *QuestionBankService) UpdateQuestion(ctx context.Context, actor Actor, id primitive.ObjectID, update *model.BankQuestion) (*model.BankQuestion, error) {
+	current, err := s.GetQuestion(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareBankQuestion(update); err != nil {
+		return nil, err
+	}
+	update.ID = current.ID
+	update.UserID = current.UserID
+	update.CreatedAt = current.CreatedAt
+	if err := s.bankRepo.Update(ctx, update); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrBankQuestionNotFound
+		}
+		return nil, err
+	}
+	return update, nil
+}
+
+func (s *QuestionBankService) DeleteQuestion(ctx context.Context, actor Actor, id primitive.ObjectID) error {
+	current, err := s.GetQuestion(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if err := s.bankRepo.Delete(ctx, current.ID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrBankQuestionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AssembleQuiz returns the quiz with the questions served for seed: its own
+// questions followed by the ones its rules draw from the owner's bank. The same
+// seed over the same bank always draws the same questions. Quizzes without rules
+// are returned as is.
+func (s *QuestionBankService) AssembleQuiz(ctx context.Context, quiz *model.Quiz, seed int64) (*model.Quiz, error) {
+	if !quiz.HasRules() {
+		return quiz, nil
+	}
+
+	rng := seededRand(seed, drawStream)
+	questions := append([]model.Question(nil), quiz.Questions...)
+	drawn := make(map[primitive.ObjectID]bool)
+	for i, rule := range quiz.Rules {
+		candidates, err := s.bankRepo.FindMatching(ctx, repo.BankFilter{UserID: quiz.UserID, Tags: rule.Tags, Difficulty: rule.Difficulty})
+		if err != nil {
+			return nil, err
+		}
+		// A question matching several rules is only served once
+		available := candidates[:0]
+		for _, c := range candidates {
+			if !drawn[c.ID] {
+				available = append(available, c)
+			}
+		}
+		if len(available) < rule.Count {
+			return nil, fmt.Errorf("%w: rules[%d] needs %d questions, %d available", ErrNotEnoughQuestions, i, rule.Count, len(available))
+		}
+		rng.Shuffle(len(available), func(a, b int) {
+			available[a], available[b] = available[b], available[a]
+		})
+		for _, c := range available[:rule.Count] {
+			drawn[c.ID] = true
+			q := c.Question
+			q.ID = c.ID
+			questions = append(questions, q)
+		}
+	}
+
+	assembled := *quiz
+	assembled.Questions = questions
+	return &assembled, nil
+}
+
+// prepareBankQuestion validates a bank question like quiz questions and
+// normalizes its tags. Problems are reported as a *ValidationError.
+func prepareBankQuestion(question *model.BankQuestion) error {
+	if errs := questionErrors(&question.Question, "question"); len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	question.Question.ID = primitive.NilObjectID
+	question.Tags = normalizeTags(question.Tags)
+	question.Difficulty = strings.TrimSpace(question.Difficulty)
+	return nil
+}
+
+// validateRules normalizes the rule tags and bounds the rule counts.
+func validateRules(verr *ValidationError, rules []model.QuestionRule) {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Count < 1 || rule.Count > maxRuleCount {
+			verr.add(fmt.Sprintf("rules[%d].count", i), fmt.Sprintf("must be between 1 and %d", maxRuleCount))
+		}
+		rule.Tags = normalizeTags(rule.Tags)
+		rule.Difficulty = strings.TrimSpace(rule.Difficulty)
+	}
+}
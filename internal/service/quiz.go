@@ -3,37 +3,63 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
 	"time"
 
 	// "fmt"
 	"slices"
+	"strings"
 	// "time"
 
 	"github.com/sachinggsingh/quiz/config"
 	"github.com/sachinggsingh/quiz/internal/model"
 	"github.com/sachinggsingh/quiz/internal/repo"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidQuiz  = errors.New("invalid quiz")
+	ErrInvalidQuery = errors.New("invalid query")
 )
 
 type QuizService struct {
 	quizRepo            repo.QuizRepo
 	userRepo            repo.UserRepo
+	attemptRepo         repo.AttemptRepo
+	versionRepo         repo.QuizVersionRepo
+	questionBank        *QuestionBankService
+	shares              *ShareService
+	subscriptions       SubscriptionService
 	leaderboard         *LeaderboardService
 	notificationService *NotificationService
 }
 
-func NewQuizService(quizRepo repo.QuizRepo, userRepo repo.UserRepo, leaderboard *LeaderboardService, notificationService *NotificationService) *QuizService {
+func NewQuizService(quizRepo repo.QuizRepo, userRepo repo.UserRepo, attemptRepo repo.AttemptRepo, versionRepo repo.QuizVersionRepo, questionBank *QuestionBankService, shares *ShareService, subscriptions SubscriptionService, leaderboard *LeaderboardService, notificationService *NotificationService) *QuizService {
 	return &QuizService{
 		quizRepo:            quizRepo,
 		userRepo:            userRepo,
+		attemptRepo:         attemptRepo,
+		versionRepo:         versionRepo,
+		questionBank:        questionBank,
+		shares:              shares,
+		subscriptions:       subscriptions,
 		leaderboard:         leaderboard,
 		notificationService: notificationService,
 	}
 }
 
-func (s *QuizService) GenerateQuiz(ctx context.Context, title string, category string, difficulty string, description string, numQuestions int, points int) (*model.Quiz, error) {
-	prompt := config.BuildPrompt(title, category, difficulty, description, numQuestions, points)
+func (s *QuizService) GenerateQuiz(ctx context.Context, title string, category string, difficulty string, description string, numQuestions int, points int, questionTypes []string) (*model.Quiz, error) {
+	for _, t := range questionTypes {
+		if !slices.Contains(model.QuestionTypes, model.QuestionType(t)) {
+			return nil, fmt.Errorf("%w: unknown question type %q", ErrInvalidQuiz, t)
+		}
+	}
+
+	prompt := config.BuildPrompt(title, category, difficulty, description, numQuestions, points, questionTypes)
 	response, err := config.GenerateContent(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate quiz from gemini: %w", err)
@@ -67,31 +93,116 @@ func (s *QuizService) GenerateQuiz(ctx context.Context, title string, category s
 	quiz.Description = description
 	quiz.Points = points
 
+	// The model doesn't always stick to the schema, so don't hand back a quiz that can't be saved
+	if err := validateQuizContent(&quiz); err != nil {
+		return nil, err
+	}
 	return &quiz, nil
 }
 
-func (s *QuizService) CreateQuiz(ctx context.Context, title string, category string, difficulty string, questions []model.Question, points int) (*model.Quiz, error) {
-	quiz := &model.Quiz{
-		Title:      title,
-		Category:   category,
-		Difficulty: difficulty,
-		Questions:  questions,
-		Points:     points,
+// CreateQuiz stores a new quiz owned by userID. Server-managed fields sent by the client are ignored.
+func (s *QuizService) CreateQuiz(ctx context.Context, userID primitive.ObjectID, quiz *model.Quiz) (*model.Quiz, error) {
+	if err := validateQuizContent(quiz); err != nil {
+		return nil, err
 	}
+	quiz.QuizID = primitive.NilObjectID
+	quiz.UserID = userID
+	quiz.Attempted = false
+	quiz.AttemptCount, quiz.AverageRating, quiz.RatingCount, quiz.QuestionCount = 0, 0, 0, 0
+	quiz.DeletedAt = nil
+	quiz.Version = 1
+	// New quizzes start as drafts; NEW_QUIZ is only sent once they are published
+	quiz.Status = model.QuizDraft
+	quiz.PublishedAt = nil
+
 	err := s.quizRepo.Create(ctx, quiz)
-	if err == nil {
-		s.notificationService.PublishQuizCreated(*quiz)
+	if err != nil {
+		return quiz, err
+	}
+	if err := s.versionRepo.Create(ctx, &model.QuizVersion{QuizID: quiz.ID, Version: 1, Quiz: *quiz, CreatedAt: quiz.CreatedAt}); err != nil {
+		return quiz, err
+	}
+	return quiz, nil
+}
+
+// validateQuizContent runs every check shared by quiz creation and edits. All the
+// problems found are reported at once as a *ValidationError.
+func validateQuizContent(quiz *model.Quiz) error {
+	quiz.Tags = normalizeTags(quiz.Tags)
+	verr := &ValidationError{Errors: structErrors(quiz, "")}
+	validateQuestions(verr, quiz.Questions)
+	validateRules(verr, quiz.Rules)
+	if !quiz.AccessTier.Valid() {
+		verr.add("access_tier", fmt.Sprintf("%q is not a known tier", quiz.AccessTier))
+	}
+	if !quiz.Visibility.Valid() {
+		verr.add("visibility", fmt.Sprintf("%q is not a known visibility", quiz.Visibility))
 	}
-	return quiz, err
+	validateRetakePolicy(verr, quiz.Retake)
+	validateScoring(verr, quiz.Scoring)
+	return verr.orNil()
 }
 
-func (s *QuizService) GetQuizzesGroupedByCategory(ctx context.Context, userID primitive.ObjectID) (map[string][]model.Quiz, error) {
-	quizzes, err := s.GetQuizzes(ctx, userID)
+// normalizeTags lowercases, trims and dedupes tags so filtering is exact.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// validateQuestions fills type defaults and runs each question type's own
+// validation on the questions the structural checks found nothing wrong with.
+func validateQuestions(verr *ValidationError, questions []model.Question) {
+	for i := range questions {
+		field := fmt.Sprintf("questions[%d]", i)
+		if verr.has(field) {
+			continue
+		}
+		verr.addQuestionError(field, prepareQuestion(&questions[i]))
+	}
+}
+
+// prepareQuestion fills the type defaults of a single question and validates it.
+func prepareQuestion(q *model.Question) error {
+	if q.Kind() == model.QuestionTrueFalse && len(q.Options) == 0 {
+		q.Options = []string{"True", "False"}
+	}
+	return q.Validate()
+}
+
+// validateRetakePolicy rejects unknown modes and rules and inconsistent limits.
+func validateRetakePolicy(verr *ValidationError, p model.RetakePolicy) {
+	switch p.Mode {
+	case "", model.RetakeSingle, model.RetakeUnlimited:
+	case model.RetakeLimited:
+		if p.MaxAttempts < 1 {
+			verr.add("retake.max_attempts", "must be at least 1")
+		}
+	default:
+		verr.add("retake.mode", fmt.Sprintf("%q is not a known mode", p.Mode))
+	}
+	switch p.Counted {
+	case "", model.CountFirst, model.CountBest, model.CountLatest:
+	default:
+		verr.add("retake.counted", fmt.Sprintf("%q is not a known rule", p.Counted))
+	}
+	if p.Cooldown < 0 {
+		verr.add("retake.cooldown", "can't be negative")
+	}
+}
+
+func (s *QuizService) GetQuizzesGroupedByCategory(ctx context.Context, actor Actor) (map[string][]model.PublicQuiz, error) {
+	quizzes, err := s.GetQuizzes(ctx, actor)
 	if err != nil {
 		return nil, err
 	}
 
-	grouped := make(map[string][]model.Quiz)
+	grouped := make(map[string][]model.PublicQuiz)
 	for _, q := range quizzes {
 		category := q.Category
 		if category == "" {
@@ -103,15 +214,16 @@ func (s *QuizService) GetQuizzesGroupedByCategory(ctx context.Context, userID pr
 	return grouped, nil
 }
 
-func (s *QuizService) GetQuizzes(ctx context.Context, userID primitive.ObjectID) ([]model.Quiz, error) {
+// GetQuizzes returns the player-facing catalog, without answer keys.
+func (s *QuizService) GetQuizzes(ctx context.Context, actor Actor) ([]model.PublicQuiz, error) {
 	quizzes, err := s.quizRepo.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// If user is authenticated, check which quizzes they've completed
-	if !userID.IsZero() {
-		user, err := s.userRepo.FindByID(ctx, userID)
+	if !actor.UserID.IsZero() {
+		user, err := s.userRepo.FindByID(ctx, actor.UserID)
 		if err == nil {
 			for i := range quizzes {
 				if slices.Contains(user.CompletedQuizIDs, quizzes[i].ID) {
@@ -120,86 +232,336 @@ func (s *QuizService) GetQuizzes(ctx context.Context, userID primitive.ObjectID)
 			}
 		}
 	}
+	s.markLocked(ctx, actor, quizzes)
+
+	public := make([]model.PublicQuiz, 0, len(quizzes))
+	for _, q := range quizzes {
+		public = append(public, q.Public())
+	}
+	return public, nil
+}
+
+// QuizQuery selects a page of the catalog.
+type QuizQuery struct {
+	Category   string
+	Difficulty string
+	Tags       []string
+	CreatorID  primitive.ObjectID
+	Attempted  *bool // nil means both, needs a user otherwise
+	Sort       string
+	Page       int64
+	Limit      int64
+	Summary    bool
+}
+
+// ListQuizzes returns a page of the catalog and the total number of matches. For
+// an authenticated user quizzes are flagged as attempted or not.
+func (s *QuizService) ListQuizzes(ctx context.Context, actor Actor, q QuizQuery) ([]model.Quiz, int64, error) {
+	switch q.Sort {
+	case "":
+		q.Sort = repo.QuizSortNewest
+	case repo.QuizSortNewest, repo.QuizSortPopular, repo.QuizSortRating:
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown sort %q", ErrInvalidQuery, q.Sort)
+	}
+
+	var completed []primitive.ObjectID
+	if !actor.UserID.IsZero() {
+		if user, err := s.userRepo.FindByID(ctx, actor.UserID); err == nil {
+			completed = user.CompletedQuizIDs
+		}
+	}
+
+	filter := repo.QuizFilter{
+		Category:   q.Category,
+		Difficulty: q.Difficulty,
+		Tags:       normalizeTags(q.Tags),
+		CreatorID:  q.CreatorID,
+	}
+	if q.Attempted != nil {
+		if actor.UserID.IsZero() {
+			return nil, 0, fmt.Errorf("%w: the attempted filter needs an authenticated user", ErrInvalidQuery)
+		}
+		if *q.Attempted {
+			filter.IDs = append([]primitive.ObjectID{}, completed...)
+		} else {
+			filter.ExcludeIDs = completed
+		}
+	}
 
+	quizzes, total, err := s.quizRepo.FindPage(ctx, filter, q.Sort, q.Page, q.Limit, q.Summary)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range quizzes {
+		quizzes[i].Attempted = slices.Contains(completed, quizzes[i].ID)
+	}
+	s.markLocked(ctx, actor, quizzes)
+	return quizzes, total, nil
+}
+
+// markLocked flags the quizzes the user's plan doesn't include. The catalog
+// still lists them so free users can see what an upgrade unlocks.
+func (s *QuizService) markLocked(ctx context.Context, actor Actor, quizzes []model.Quiz) {
+	tier := userTier(ctx, s.subscriptions, actor.UserID)
+	for i := range quizzes {
+		quizzes[i].Locked = isLocked(actor, tier, &quizzes[i])
+	}
+}
+
+// GetMyQuizzes returns the quizzes created by the user, answer keys included.
+func (s *QuizService) GetMyQuizzes(ctx context.Context, userID primitive.ObjectID) ([]model.Quiz, error) {
+	quizzes, err := s.quizRepo.FindAllByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if quizzes == nil {
+		quizzes = []model.Quiz{}
+	}
 	return quizzes, nil
 }
 
+// GetQuizForViewer returns a quiz the actor is allowed to see. Quizzes that
+// aren't published are only visible to the people managing or reviewing them,
+// and unlisted or private ones also to the holders of a share link.
+func (s *QuizService) GetQuizForViewer(ctx context.Context, actor Actor, id primitive.ObjectID, shareToken string) (*model.Quiz, error) {
+	quiz, err := s.findQuiz(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canViewQuiz(actor, quiz) {
+		return nil, ErrQuizNotFound
+	}
+	if err := s.shares.CheckVisibility(ctx, actor, quiz, shareToken); err != nil {
+		return nil, err
+	}
+	if err := checkQuizAccess(ctx, s.subscriptions, actor, quiz); err != nil {
+		return nil, err
+	}
+	return quiz, nil
+}
+
+// GetQuizByID returns the full quiz including answer keys. Callers serving
+// players must use Quiz.Public.
 func (s *QuizService) GetQuizByID(ctx context.Context, id primitive.ObjectID) (*model.Quiz, error) {
 	return s.quizRepo.FindByID(ctx, id)
 }
 
-func (s *QuizService) SubmitQuiz(ctx context.Context, userID primitive.ObjectID, quizID primitive.ObjectID, answers map[string]string) (int, error) {
+// CheckQuizAccess refuses quizzes out of the user's plan. Live games use it to
+// hold the host to the same plan as someone starting an attempt.
+func (s *QuizService) CheckQuizAccess(ctx context.Context, userID string, role string, quiz *model.Quiz) error {
+	id, _ := primitive.ObjectIDFromHex(userID)
+	return checkQuizAccess(ctx, s.subscriptions, NewActor(id, role), quiz)
+}
+
+// AssembleQuiz returns the quiz with the questions its rules draw from the
+// question bank for seed. Live games use it to serve every player the same set.
+func (s *QuizService) AssembleQuiz(ctx context.Context, quiz *model.Quiz, seed int64) (*model.Quiz, error) {
+	return s.questionBank.AssembleQuiz(ctx, quiz, seed)
+}
+
+// SubmissionResult is the outcome of a graded submission.
+type SubmissionResult struct {
+	AttemptID primitive.ObjectID     `json:"attempt_id"`
+	Score     int                    `json:"score"`
+	Review    []model.QuestionResult `json:"review"`
+}
+
+// SubmitQuiz grades the answers of an open attempt. Unknown, closed or late attempts are rejected.
+// Answers are keyed by the position the attempt showed the question at; each value is whatever
+// the question type expects, option indexes referring to the options as they were shown.
+func (s *QuizService) SubmitQuiz(ctx context.Context, actor Actor, quizID primitive.ObjectID, attemptID primitive.ObjectID, answers map[string]any) (*SubmissionResult, error) {
+	quiz, attempt, err := s.openSubmission(ctx, actor, quizID, attemptID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// grading stays server side
+	if err := s.gradeAttempt(ctx, quiz, attempt, answers, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.recordAttempt(ctx, quiz, attempt); err != nil {
+		return nil, err
+	}
+
+	// TRIGGER REAL-TIME UPDATE
+	s.leaderboard.BroadcastUpdate()
+
+	return &SubmissionResult{AttemptID: attempt.ID, Score: attempt.Score, Review: attempt.Results}, nil
+}
+
+// SubmitPractice grades a practice attempt and stores its review like SubmitQuiz,
+// but leaves the user's stats, the quiz's attempt count and the leaderboard alone.
+func (s *QuizService) SubmitPractice(ctx context.Context, actor Actor, quizID primitive.ObjectID, attemptID primitive.ObjectID, answers map[string]any) (*SubmissionResult, error) {
+	quiz, attempt, err := s.openSubmission(ctx, actor, quizID, attemptID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.gradeAttempt(ctx, quiz, attempt, answers, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.recordAttempt(ctx, quiz, attempt); err != nil {
+		return nil, err
+	}
+
+	return &SubmissionResult{AttemptID: attempt.ID, Score: attempt.Score, Review: attempt.Results}, nil
+}
+
+// openSubmission loads the quiz and the open attempt being submitted, and checks
+// the attempt is submitted in the mode it was started in.
+func (s *QuizService) openSubmission(ctx context.Context, actor Actor, quizID primitive.ObjectID, attemptID primitive.ObjectID, practice bool) (*model.Quiz, *model.Attempt, error) {
 	quiz, err := s.quizRepo.FindByID(ctx, quizID)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
 
-	user, err := s.userRepo.FindByID(ctx, userID)
+	attempt, err := openAttempt(ctx, s.attemptRepo, attemptID, actor.UserID, quiz.ID)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
+	}
+	if attempt.Practice != practice {
+		return nil, nil, ErrAttemptMode
+	}
+	// The plan is checked again in case the subscription ended during the attempt
+	if err := checkQuizAccess(ctx, s.subscriptions, actor, quiz); err != nil {
+		return nil, nil, err
 	}
+	return quiz, attempt, nil
+}
 
-	// calculating correct answers
-	correctCount := 0
-	for i, q := range quiz.Questions {
-		idxStr := fmt.Sprintf("%d", i) // converting int to string
-		if ansStr, ok := answers[idxStr]; ok {
-			if ansStr == fmt.Sprintf("%d", q.Answer) {
-				correctCount++
-			}
-		}
+// gradeAttempt grades the answers and fills in the attempt's results, ready to be
+// stored. Answers saved during the attempt are graded too, unless answers overrides them.
+func (s *QuizService) gradeAttempt(ctx context.Context, quiz *model.Quiz, attempt *model.Attempt, answers map[string]any, now time.Time) error {
+	if len(attempt.Answers) > 0 {
+		merged := maps.Clone(attempt.Answers)
+		maps.Copy(merged, answers)
+		answers = merged
 	}
-	// calculating points
-	earnedPoints := 0
-	if len(quiz.Questions) > 0 {
-		earnedPoints = (quiz.Points * correctCount) / len(quiz.Questions)
+
+	// Grade against the content the attempt was started on
+	played, err := playedQuiz(ctx, s.versionRepo, quiz, attempt)
+	if err != nil {
+		return err
 	}
 
-	// Logic to update user stats integrated here
-	alreadyCompleted := slices.Contains(user.CompletedQuizIDs, quizID)
+	// Answers refer to the attempt's own question and option order
+	results, score, correctCount := gradeAnswers(played, canonicalAnswers(attempt, played.Questions, answers), canonicalHints(attempt, played.Questions), now.Sub(attempt.StartedAt))
+	results = reviewInAttemptOrder(attempt, played.Questions, results, answers)
+
+	attempt.EndedAt = &now
+	attempt.Score = score.Points
+	attempt.SpeedBonus = score.SpeedBonus
+	attempt.Correct = correctCount
+	attempt.Percentage = score.Percentage
+	attempt.Results = results
+	return nil
+}
+
+// recordAttempt stores a graded attempt. Ranked attempts are folded into the user's
+// stats first; practice attempts are only stored.
+func (s *QuizService) recordAttempt(ctx context.Context, quiz *model.Quiz, attempt *model.Attempt) error {
+	// The claim takes the attempt out of in_progress, so however many submissions
+	// of it race, even ones that loaded it long before, only one gets past here.
+	if err := s.attemptRepo.Claim(ctx, attempt.ID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrAttemptClosed
+		}
+		return err
+	}
 
-	totalQuizzes := len(user.CompletedQuizIDs)
-	newTotalScore := user.Score
-	newCompletedQuizzes := totalQuizzes + 1
-	newAverageScore := user.AverageScore
+	if !attempt.Practice {
+		// The stats update is guarded on their version. Submissions of other quizzes
+		// racing this one are retried on top of each other, and an attempt the retake
+		// policy no longer allows is rejected.
+		now := *attempt.EndedAt
+		_, err := updateUserStats(ctx, s.userRepo, attempt.UserID, func(user *model.User) error {
+			if err := checkRetakePolicy(quiz, user, now); err != nil {
+				return err
+			}
+			applyAttemptToStats(user, quiz, attempt.ID, attempt.Score, attempt.Percentage, now)
+			return nil
+		})
+		if err != nil {
+			// Nothing was counted, so the attempt can be submitted again
+			if err := s.attemptRepo.Release(ctx, attempt.ID); err != nil {
+				log.Printf("Error releasing attempt %s: %v", attempt.ID.Hex(), err)
+			}
+			return err
+		}
+	}
 
-	if alreadyCompleted {
-		return 0, fmt.Errorf("quiz already attempted")
+	if err := s.attemptRepo.Submit(ctx, attempt); err != nil {
+		if attempt.Practice {
+			if err := s.attemptRepo.Release(ctx, attempt.ID); err != nil {
+				log.Printf("Error releasing attempt %s: %v", attempt.ID.Hex(), err)
+			}
+		} else {
+			// Releasing it would let the attempt count twice
+			log.Printf("Attempt %s was counted in the stats of user %s but its results weren't stored: %v", attempt.ID.Hex(), attempt.UserID.Hex(), err)
+		}
+		return err
+	}
+	if attempt.Practice {
+		return nil
 	}
 
-	newTotalScore += earnedPoints
-	// Re-calculate quiz percentage for average score
-	quizPercentage := 0
-	if len(quiz.Questions) > 0 {
-		quizPercentage = (correctCount * 100) / len(quiz.Questions)
+	if err := s.quizRepo.IncrementAttemptCount(ctx, quiz.ID); err != nil {
+		log.Printf("Error updating attempt count of quiz %s: %v", quiz.ID.Hex(), err)
 	}
-	newAverageScore = (user.AverageScore*float64(totalQuizzes) + float64(quizPercentage)) / float64(newCompletedQuizzes)
-	user.CompletedQuizIDs = append(user.CompletedQuizIDs, quizID)
+	return nil
+}
 
-	newStreak := user.Streak
-	if len(quiz.Questions) > 0 {
-		quizPercentage = (correctCount * 100) / len(quiz.Questions)
+// applyAttemptToStats folds a graded attempt into the user's aggregate stats. On a
+// retake, Score and AverageScore only move if the quiz's counted attempt rule selects it.
+func applyAttemptToStats(user *model.User, quiz *model.Quiz, attemptID primitive.ObjectID, points int, percentage int, now time.Time) {
+	stat, tracked := userQuizStat(user, quiz.ID)
+	completed := len(user.CompletedQuizIDs)
+
+	if stat.Attempts == 0 {
+		user.Score += points
+		user.AverageScore = (user.AverageScore*float64(completed) + float64(percentage)) / float64(completed+1)
+		user.CompletedQuizIDs = append(user.CompletedQuizIDs, quiz.ID)
+		stat.Points = points
+		stat.Percentage = percentage
+		stat.CountedAttemptID = attemptID
+	} else if tracked {
+		counted := false
+		switch quiz.Retake.CountedRule() {
+		case model.CountBest:
+			counted = points > stat.Points || (points == stat.Points && percentage > stat.Percentage)
+		case model.CountLatest:
+			counted = true
+		}
+		if counted {
+			user.Score += points - stat.Points
+			if completed > 0 {
+				user.AverageScore += float64(percentage-stat.Percentage) / float64(completed)
+			}
+			stat.Points = points
+			stat.Percentage = percentage
+			stat.CountedAttemptID = attemptID
+		}
 	}
-	if quizPercentage >= 70 {
-		newStreak++
+	// Untracked quizzes (completed before per-quiz stats existed) keep their original contribution
+	stat.Attempts++
+	stat.LastAttemptAt = now
+	stat.LastAttemptID = attemptID
+
+	if percentage >= 70 {
+		user.Streak++
 	} else {
-		newStreak = 0
+		user.Streak = 0
 	}
 
 	// Update Activity
-	today := time.Now().Format("2006-01-02")
 	if user.Activity == nil {
 		user.Activity = make(map[string]int)
 	}
-	user.Activity[today]++
+	user.Activity[now.Format("2006-01-02")]++
 
-	err = s.userRepo.UpdateStats(ctx, userID, newTotalScore, newCompletedQuizzes, newAverageScore, newStreak, user.Activity, user.CompletedQuizIDs)
-	if err != nil {
-		return 0, err
+	if user.QuizStats == nil {
+		user.QuizStats = make(map[string]model.QuizStat)
 	}
-
-	// TRIGGER REAL-TIME UPDATE
-	s.leaderboard.BroadcastUpdate()
-
-	return earnedPoints, nil
+	user.QuizStats[quiz.ID.Hex()] = stat
 }
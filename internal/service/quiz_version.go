@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrQuizNotFound    = errors.New("quiz not found")
+	ErrVersionNotFound = errors.New("quiz version not found")
+	ErrQuizConflict    = errors.New("quiz was modified by another request")
+)
+
+// QuizPatch is a partial quiz update. Nil fields are left untouched.
+type QuizPatch struct {
+	Title       *string               `json:"title"`
+	Category    *string               `json:"category"`
+	Description *string               `json:"description"`
+	Difficulty  *string               `json:"difficulty"`
+	Tags        *[]string             `json:"tags"`
+	Questions   *[]model.Question     `json:"questions"`
+	Rules       *[]model.QuestionRule `json:"rules"`
+	Points      *int                  `json:"points"`
+	TimeLimit   *int                  `json:"time_limit"`
+	Retake      *model.RetakePolicy   `json:"retake"`
+	Scoring     *model.ScoringConfig  `json:"scoring"`
+	KeepOrder   *bool                 `json:"keep_order"`
+	AccessTier  *model.AccessTier     `json:"access_tier"`
+	Visibility  *model.Visibility     `json:"visibility"`
+}
+
+func (p QuizPatch) apply(quiz *model.Quiz) {
+	if p.Title != nil {
+		quiz.Title = *p.Title
+	}
+	if p.Category != nil {
+		quiz.Category = *p.Category
+	}
+	if p.Description != nil {
+		quiz.Description = *p.Description
+	}
+	if p.Difficulty != nil {
+		quiz.Difficulty = *p.Difficulty
+	}
+	if p.Tags != nil {
+		quiz.Tags = *p.Tags
+	}
+	if p.Questions != nil {
+		quiz.Questions = *p.Questions
+	}
+	if p.Rules != nil {
+		quiz.Rules = *p.Rules
+	}
+	if p.Points != nil {
+		quiz.Points = *p.Points
+	}
+	if p.TimeLimit != nil {
+		quiz.TimeLimit = *p.TimeLimit
+	}
+	if p.Retake != nil {
+		quiz.Retake = *p.Retake
+	}
+	if p.Scoring != nil {
+		quiz.Scoring = *p.Scoring
+	}
+	if p.KeepOrder != nil {
+		quiz.KeepOrder = *p.KeepOrder
+	}
+	if p.AccessTier != nil {
+		quiz.AccessTier = *p.AccessTier
+	}
+	if p.Visibility != nil {
+		quiz.Visibility = *p.Visibility
+	}
+}
+
+// UpdateQuiz replaces the content of a quiz and records it as a new version.
+func (s *QuizService) UpdateQuiz(ctx context.Context, actor Actor, id primitive.ObjectID, quiz *model.Quiz) (*model.Quiz, error) {
+	current, err := s.findManagedQuiz(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveVersion(ctx, actor, current, quiz)
+}
+
+// PatchQuiz applies a partial update and records it as a new version.
+func (s *QuizService) PatchQuiz(ctx context.Context, actor Actor, id primitive.ObjectID, patch QuizPatch) (*model.Quiz, error) {
+	current, err := s.findManagedQuiz(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	next := *current
+	next.Questions = append([]model.Question(nil), current.Questions...)
+	patch.apply(&next)
+	return s.saveVersion(ctx, actor, current, &next)
+}
+
+// DeleteQuiz soft deletes a quiz. Its versions and the attempts made on it are kept.
+func (s *QuizService) DeleteQuiz(ctx context.Context, actor Actor, id primitive.ObjectID) error {
+	current, err := s.findQuiz(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !canDeleteQuiz(actor, current) {
+		return ErrNotQuizOwner
+	}
+	if err := s.quizRepo.SoftDelete(ctx, current.ID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrQuizNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListVersions returns the version history of a quiz, newest first. Snapshots carry
+// the answer keys, so only the people managing the quiz can see them.
+func (s *QuizService) ListVersions(ctx context.Context, actor Actor, id primitive.ObjectID) ([]model.QuizVersion, error) {
+	current, err := s.findManagedQuiz(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.versionRepo.FindByQuiz(ctx, current.ID)
+}
+
+// RollbackQuiz restores the content of an earlier version. The rollback is itself
+// a new version, so the history is never rewritten.
+func (s *QuizService) RollbackQuiz(ctx context.Context, actor Actor, id primitive.ObjectID, version int) (*model.Quiz, error) {
+	current, err := s.findManagedQuiz(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.versionRepo.FindOne(ctx, current.ID, version)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, err
+	}
+	// Rolling back content never publishes a quiz that was made unlisted or private since
+	restored := snapshot.Quiz
+	restored.Visibility = current.Visibility
+	return s.saveVersion(ctx, actor, current, &restored)
+}
+
+func (s *QuizService) findQuiz(ctx context.Context, id primitive.ObjectID) (*model.Quiz, error) {
+	quiz, err := s.quizRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrQuizNotFound
+		}
+		return nil, err
+	}
+	return quiz, nil
+}
+
+// findManagedQuiz loads a quiz the actor is allowed to manage.
+func (s *QuizService) findManagedQuiz(ctx context.Context, actor Actor, id primitive.ObjectID) (*model.Quiz, error) {
+	quiz, err := s.findQuiz(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageQuiz(actor, quiz) {
+		return nil, ErrNotQuizOwner
+	}
+	return quiz, nil
+}
+
+// saveVersion validates next, stores its snapshot and makes it the current content
+// of the quiz. The unique (quiz_id, version) index makes concurrent edits of the
+// same version fail instead of overwriting each other. The snapshot goes in first,
+// so every version a player can start is in the history, and is removed again if
+// the quiz update loses. Edits to the questions of a published or scheduled quiz
+// send it back to review unless a reviewer made them, so unreviewed questions
+// never go live; metadata, tag and visibility edits stay live.
+func (s *QuizService) saveVersion(ctx context.Context, actor Actor, current *model.Quiz, next *model.Quiz) (*model.Quiz, error) {
+	if err := validateQuizContent(next); err != nil {
+		return nil, err
+	}
+
+	// Quizzes created before versioning get their original content recorded as version 1
+	if current.Version == 0 {
+		legacy := *current
+		legacy.Version = 1
+		if err := s.versionRepo.Create(ctx, &model.QuizVersion{QuizID: current.ID, Version: 1, Quiz: legacy, CreatedAt: current.UpdatedAt}); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	next.ID = current.ID
+	next.QuizID = current.QuizID
+	next.UserID = current.UserID
+	next.Status = current.Status
+	if !isReviewer(actor) && questionsChanged(current, next) {
+		switch current.QuizStatus() {
+		case model.QuizPublished, model.QuizScheduled:
+			next.Status = model.QuizInReview
+		}
+	}
+	next.PublishAt = current.PublishAt
+	next.PublishedAt = current.PublishedAt
+	next.AttemptCount = current.AttemptCount
+	next.AverageRating = current.AverageRating
+	next.RatingCount = current.RatingCount
+	next.QuestionCount = 0
+	next.CreatedAt = current.CreatedAt
+	next.UpdatedAt = now
+	next.DeletedAt = nil
+	next.Attempted = false
+	next.Version = max(current.Version, 1) + 1
+	for i := range next.Questions {
+		if next.Questions[i].ID.IsZero() {
+			next.Questions[i].ID = primitive.NewObjectID()
+		}
+	}
+
+	snapshot := &model.QuizVersion{QuizID: next.ID, Version: next.Version, Quiz: *next, CreatedAt: now}
+	if err := s.versionRepo.Create(ctx, snapshot); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrQuizConflict
+		}
+		return nil, err
+	}
+	if err := s.quizRepo.Update(ctx, next, current.Version); err != nil {
+		if delErr := s.versionRepo.Delete(ctx, snapshot.ID); delErr != nil {
+			log.Printf("Error discarding unapplied version %d of quiz %s: %v", next.Version, next.ID.Hex(), delErr)
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrQuizConflict
+		}
+		return nil, err
+	}
+	return next, nil
+}
+
+// playedQuiz returns the quiz content the attempt was started on, even if the
+// quiz was edited since, with the questions the attempt was served when they
+// were drawn from the question bank.
+func playedQuiz(ctx context.Context, versionRepo repo.QuizVersionRepo, quiz *model.Quiz, attempt *model.Attempt) (*model.Quiz, error) {
+	played := quiz
+	if attempt.QuizVersion != quiz.Version {
+		snapshot, err := versionRepo.FindOne(ctx, quiz.ID, max(attempt.QuizVersion, 1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load version %d of quiz %s: %w", attempt.QuizVersion, quiz.ID.Hex(), err)
+		}
+		played = &snapshot.Quiz
+	}
+	if len(attempt.Questions) > 0 {
+		served := *played
+		served.Questions = attempt.Questions
+		played = &served
+	}
+	return played, nil
+}
+
+// questionsChanged reports whether next changes what players are asked or how
+// they are graded: the questions with their answer keys and hints, or the bank
+// rules. Question ids are ignored, as edits may leave new questions without one.
+func questionsChanged(current *model.Quiz, next *model.Quiz) bool {
+	if len(current.Questions) != len(next.Questions) || len(current.Rules) != len(next.Rules) {
+		return true
+	}
+	for i := range current.Questions {
+		if !reflect.DeepEqual(comparableQuestion(current.Questions[i]), comparableQuestion(next.Questions[i])) {
+			return true
+		}
+	}
+	for i := range current.Rules {
+		a, b := current.Rules[i], next.Rules[i]
+		if a.Count != b.Count || a.Difficulty != b.Difficulty || !slices.Equal(a.Tags, b.Tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// comparableQuestion drops the question id and turns empty lists into nil, so
+// a question read back from Mongo equals the same question sent by a client.
+func comparableQuestion(q model.Question) model.Question {
+	q.ID = primitive.NilObjectID
+	if len(q.Options) == 0 {
+		q.Options = nil
+	}
+	if len(q.Answers) == 0 {
+		q.Answers = nil
+	}
+	if len(q.Order) == 0 {
+		q.Order = nil
+	}
+	if len(q.AcceptedAnswers) == 0 {
+		q.AcceptedAnswers = nil
+	}
+	if len(q.Hints) == 0 {
+		q.Hints = nil
+	}
+	return q
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func versionedQuiz(owner primitive.ObjectID) model.Quiz {
+	return model.Quiz{
+		ID:        primitive.NewObjectID(),
+		UserID:    owner,
+		Title:     "Capitals",
+		Points:    10,
+		Status:    model.QuizDraft,
+		Version:   1,
+		Questions: []model.Question{{Text: "Capital of France?", Options: []string{"Paris", "Lyon"}, Answer: 0}},
+	}
+}
+
+// staleUpdateRepo lets the edit read the quiz, then bumps the version before the
+// guarded update, like a concurrent edit landing in between.
+type staleUpdateRepo struct {
+	*fakeQuizRepo
+}
+
+func (r staleUpdateRepo) Update(ctx context.Context, quiz *model.Quiz, version int) error {
+	r.mu.Lock()
+	r.quiz.Version++
+	r.mu.Unlock()
+	return r.fakeQuizRepo.Update(ctx, quiz, version)
+}
+
+func TestSaveVersionConflictLeavesNoSnapshot(t *testing.T) {
+	owner := primitive.NewObjectID()
+	quizzes := &fakeQuizRepo{quiz: versionedQuiz(owner)}
+	versions := &fakeVersionRepo{}
+	s := NewQuizService(staleUpdateRepo{quizzes}, nil, nil, versions, nil, nil, nil, nil, nil)
+
+	title := "European capitals"
+	_, err := s.PatchQuiz(context.Background(), NewActor(owner, string(model.RoleCreator)), quizzes.quiz.ID, QuizPatch{Title: &title})
+	if !errors.Is(err, ErrQuizConflict) {
+		t.Fatalf("got %v, want ErrQuizConflict", err)
+	}
+	if len(versions.versions) != 0 {
+		t.Errorf("%d snapshots left in the history, want none", len(versions.versions))
+	}
+	if quizzes.quiz.Title != "Capitals" {
+		t.Errorf("quiz title is %q, want it unchanged", quizzes.quiz.Title)
+	}
+}
+
+func TestSaveVersionRecordsSnapshot(t *testing.T) {
+	owner := primitive.NewObjectID()
+	quizzes := &fakeQuizRepo{quiz: versionedQuiz(owner)}
+	versions := &fakeVersionRepo{}
+	s := NewQuizService(quizzes, nil, nil, versions, nil, nil, nil, nil, nil)
+
+	title := "European capitals"
+	saved, err := s.PatchQuiz(context.Background(), NewActor(owner, string(model.RoleCreator)), quizzes.quiz.ID, QuizPatch{Title: &title})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Version != 2 || quizzes.quiz.Version != 2 {
+		t.Errorf("saved version %d, stored version %d, want 2", saved.Version, quizzes.quiz.Version)
+	}
+	if len(versions.versions) != 1 || versions.versions[0].Version != 2 || versions.versions[0].Quiz.Title != title {
+		t.Errorf("history is %+v, want the single version 2 snapshot", versions.versions)
+	}
+}
+
+func TestSaveVersionSendsLiveQuizzesBackToReview(t *testing.T) {
+	title := "European capitals"
+	tags := []string{"geography"}
+	unlisted := model.VisibilityUnlisted
+	questions := []model.Question{{Text: "Capital of France?", Options: []string{"Lyon", "Paris"}, Answer: 1}}
+	sameQuestions := []model.Question{{Text: "Capital of France?", Options: []string{"Paris", "Lyon"}, Answer: 0, Hints: []model.Hint{}}}
+	rules := []model.QuestionRule{{Count: 2, Tags: []string{"capitals"}}}
+
+	tests := []struct {
+		name   string
+		role   model.Role
+		status model.QuizStatus
+		patch  QuizPatch
+		want   model.QuizStatus
+	}{
+		{"creator edits the questions of a published quiz", model.RoleCreator, model.QuizPublished, QuizPatch{Questions: &questions}, model.QuizInReview},
+		{"creator edits the questions of a scheduled quiz", model.RoleCreator, model.QuizScheduled, QuizPatch{Questions: &questions}, model.QuizInReview},
+		{"creator edits the questions of a quiz from before the workflow", model.RoleCreator, "", QuizPatch{Questions: &questions}, model.QuizInReview},
+		{"creator adds bank rules to a published quiz", model.RoleCreator, model.QuizPublished, QuizPatch{Rules: &rules}, model.QuizInReview},
+		{"creator renames a published quiz", model.RoleCreator, model.QuizPublished, QuizPatch{Title: &title}, model.QuizPublished},
+		{"creator tags a published quiz", model.RoleCreator, model.QuizPublished, QuizPatch{Tags: &tags}, model.QuizPublished},
+		{"creator unlists a published quiz", model.RoleCreator, model.QuizPublished, QuizPatch{Visibility: &unlisted}, model.QuizPublished},
+		{"creator resends the same questions", model.RoleCreator, model.QuizPublished, QuizPatch{Title: &title, Questions: &sameQuestions}, model.QuizPublished},
+		{"creator edits the questions of a draft", model.RoleCreator, model.QuizDraft, QuizPatch{Questions: &questions}, model.QuizDraft},
+		{"creator edits the questions of an archived quiz", model.RoleCreator, model.QuizArchived, QuizPatch{Questions: &questions}, model.QuizArchived},
+		{"admin edits the questions of a published quiz", model.RoleAdmin, model.QuizPublished, QuizPatch{Questions: &questions}, model.QuizPublished},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner := primitive.NewObjectID()
+			quiz := versionedQuiz(owner)
+			quiz.Status = tt.status
+			quiz.Questions[0].ID = primitive.NewObjectID()
+			quizzes := &fakeQuizRepo{quiz: quiz}
+			s := NewQuizService(quizzes, nil, nil, &fakeVersionRepo{}, nil, nil, nil, nil, nil)
+
+			saved, err := s.PatchQuiz(context.Background(), NewActor(owner, string(tt.role)), quiz.ID, tt.patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.Status != tt.want || quizzes.quiz.Status != tt.want {
+				t.Errorf("saved status %q, stored status %q, want %q", saved.Status, quizzes.quiz.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidRating    = errors.New("invalid rating")
+	ErrRatingNotAllowed = errors.New("only players who completed the quiz can rate it")
+)
+
+type RatingService struct {
+	ratingRepo repo.RatingRepo
+	quizRepo   repo.QuizRepo
+	userRepo   repo.UserRepo
+	shares     *ShareService
+	validator  *validator.Validate
+}
+
+func NewRatingService(ratingRepo repo.RatingRepo, quizRepo repo.QuizRepo, userRepo repo.UserRepo, shares *ShareService) *RatingService {
+	return &RatingService{
+		ratingRepo: ratingRepo,
+		quizRepo:   quizRepo,
+		userRepo:   userRepo,
+		shares:     shares,
+		validator:  validator.New(),
+	}
+}
+
+// RatingResult is a stored rating with the quiz's rating counters after it.
+type RatingResult struct {
+	Rating        *model.Rating `json:"rating"`
+	AverageRating float64       `json:"average_rating"`
+	RatingCount   int           `json:"rating_count"`
+}
+
+// RateQuiz stores the user's 1-5 star rating of a quiz and their optional review.
+// Only ranked submissions count as completing the quiz. Rating again replaces
+// the previous rating.
+func (s *RatingService) RateQuiz(ctx context.Context, userID primitive.ObjectID, quizID primitive.ObjectID, stars int, review string) (*RatingResult, error) {
+	rating := &model.Rating{UserID: userID, Stars: stars, Review: strings.TrimSpace(review)}
+	if err := s.validator.Struct(rating); err != nil {
+		return nil, fmt.Errorf("%w: stars must be between 1 and 5 and the review at most 500 characters", ErrInvalidRating)
+	}
+
+	quiz, err := s.quizRepo.FindByID(ctx, quizID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrQuizNotFound
+		}
+		return nil, err
+	}
+	// Like their reviews, quizzes that aren't published can't be rated
+	if !quiz.IsPublic() {
+		return nil, ErrQuizNotFound
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if stat, _ := userQuizStat(user, quiz.ID); stat.Attempts == 0 {
+		return nil, ErrRatingNotAllowed
+	}
+
+	rating.QuizID = quiz.ID
+	rating.UserName = user.Name
+	if err := s.ratingRepo.Upsert(ctx, rating); err != nil {
+		return nil, err
+	}
+	// The counters are recomputed from every rating, so a rating stored by a
+	// request that fails here is counted by the next one
+	if err := s.ratingRepo.RefreshQuizRating(ctx, quiz.ID); err != nil {
+		return nil, err
+	}
+	counters, err := s.quizRepo.FindByID(ctx, quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &RatingResult{Rating: rating, AverageRating: counters.AverageRating, RatingCount: counters.RatingCount}, nil
+}
+
+// ListReviews returns a page of a quiz's ratings, most recently updated first.
+// withReview keeps only those that came with a review. Unlisted and private
+// quizzes need the viewer's share token like the quiz itself.
+func (s *RatingService) ListReviews(ctx context.Context, actor Actor, quizID primitive.ObjectID, shareToken string, withReview bool, page int64, limit int64) ([]model.Rating, int64, error) {
+	quiz, err := s.quizRepo.FindByID(ctx, quizID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, 0, ErrQuizNotFound
+		}
+		return nil, 0, err
+	}
+	if !quiz.IsPublic() && !canManageQuiz(actor, quiz) {
+		return nil, 0, ErrQuizNotFound
+	}
+	if err := s.shares.CheckVisibility(ctx, actor, quiz, shareToken); err != nil {
+		return nil, 0, err
+	}
+	return s.ratingRepo.FindByQuiz(ctx, quiz.ID, withReview, page, limit)
+}
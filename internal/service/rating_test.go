@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ratingSetup returns a rating service for quiz and two players, the first of
+// whom completed it.
+func ratingSetup(quiz model.Quiz) (*RatingService, *fakeRatingRepo, primitive.ObjectID, primitive.ObjectID) {
+	played, other := primitive.NewObjectID(), primitive.NewObjectID()
+	users := &fakeUserRepo{users: map[primitive.ObjectID]model.User{
+		played: {UserId: played, Name: "Ada", QuizStats: map[string]model.QuizStat{quiz.ID.Hex(): {Attempts: 1}}},
+		other:  {UserId: other, Name: "Bob"},
+	}}
+	quizzes := &fakeQuizRepo{quiz: quiz}
+	ratings := &fakeRatingRepo{quizzes: quizzes}
+	return NewRatingService(ratings, quizzes, users, nil), ratings, played, other
+}
+
+func TestRateQuiz(t *testing.T) {
+	published := versionedQuiz(primitive.NewObjectID())
+	published.Status = model.QuizPublished
+
+	tests := []struct {
+		name    string
+		status  model.QuizStatus
+		played  bool
+		stars   int
+		wantErr error
+	}{
+		{"completed a published quiz", model.QuizPublished, true, 4, nil},
+		{"quiz from before the workflow", "", true, 4, nil},
+		{"never completed the quiz", model.QuizPublished, false, 4, ErrRatingNotAllowed},
+		{"archived quiz", model.QuizArchived, true, 4, ErrQuizNotFound},
+		{"quiz back in review", model.QuizInReview, true, 4, ErrQuizNotFound},
+		{"too many stars", model.QuizPublished, true, 6, ErrInvalidRating},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz := published
+			quiz.Status = tt.status
+			s, ratings, played, other := ratingSetup(quiz)
+			user := other
+			if tt.played {
+				user = played
+			}
+
+			result, err := s.RateQuiz(context.Background(), user, quiz.ID, tt.stars, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(ratings.ratings) != 0 {
+					t.Errorf("%d ratings stored, want none", len(ratings.ratings))
+				}
+				return
+			}
+			if result.RatingCount != 1 || result.AverageRating != float64(tt.stars) {
+				t.Errorf("counters are %d ratings averaging %v, want 1 averaging %d", result.RatingCount, result.AverageRating, tt.stars)
+			}
+		})
+	}
+}
+
+func TestRateQuizAgainReplacesTheRating(t *testing.T) {
+	quiz := versionedQuiz(primitive.NewObjectID())
+	quiz.Status = model.QuizPublished
+	s, ratings, played, _ := ratingSetup(quiz)
+	ctx := context.Background()
+
+	first, err := s.RateQuiz(ctx, played, quiz.ID, 2, "Too hard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.RateQuiz(ctx, played, quiz.ID, 5, "  Got it on the retake  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ratings.ratings) != 1 {
+		t.Fatalf("%d ratings stored, want the one replaced", len(ratings.ratings))
+	}
+	stored := ratings.ratings[0]
+	if stored.ID != first.Rating.ID || stored.Stars != 5 || stored.Review != "Got it on the retake" || stored.UserName != "Ada" {
+		t.Errorf("stored rating is %+v, want the first one updated to 5 stars and the trimmed review", stored)
+	}
+	if second.RatingCount != 1 || second.AverageRating != 5 {
+		t.Errorf("counters are %d ratings averaging %v, want 1 averaging 5", second.RatingCount, second.AverageRating)
+	}
+}
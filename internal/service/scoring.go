@@ -0,0 +1,213 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+// defaultPenalty is the share of a question's value lost for a wrong answer
+// under negative marking when the quiz doesn't configure one.
+const defaultPenalty = 0.25
+
+// defaultHintPenalty is the share of a question's value lost per hint revealed
+// when the quiz doesn't configure one.
+const defaultHintPenalty = 0.25
+
+// GradedQuestion is the input of a scoring strategy for one question.
+type GradedQuestion struct {
+	Answered bool
+	Credit   float64 // between 0 and 1
+	Hints    int     // hints revealed
+}
+
+// Score is the outcome of a scoring strategy.
+type Score struct {
+	QuestionPoints []float64
+	Points         int // total, speed bonus included
+	SpeedBonus     int
+	Percentage     int // share of the quiz points earned, speed bonus excluded
+}
+
+// ScoringStrategy turns graded questions into points. Strategies are selected
+// per quiz through model.ScoringConfig.
+type ScoringStrategy interface {
+	Score(quiz *model.Quiz, graded []GradedQuestion, elapsed time.Duration) Score
+}
+
+var scoringStrategies = map[model.ScoringStrategy]ScoringStrategy{
+	model.ScoreProportional:   proportionalScoring{},
+	model.ScoreWeighted:       weightedScoring{},
+	model.ScoreNegativeMarked: negativeMarking{},
+}
+
+// scoringFor returns the quiz's strategy, defaulting to proportional scoring.
+func scoringFor(quiz *model.Quiz) ScoringStrategy {
+	if strategy, ok := scoringStrategies[quiz.Scoring.Strategy]; ok {
+		return strategy
+	}
+	return proportionalScoring{}
+}
+
+// proportionalScoring splits the quiz points evenly across questions.
+type proportionalScoring struct{}
+
+func (proportionalScoring) Score(quiz *model.Quiz, graded []GradedQuestion, elapsed time.Duration) Score {
+	values := questionValues(quiz, false)
+	return sumPoints(quiz, values, graded, 0, elapsed)
+}
+
+// weightedScoring splits the quiz points according to each question's weight.
+type weightedScoring struct{}
+
+func (weightedScoring) Score(quiz *model.Quiz, graded []GradedQuestion, elapsed time.Duration) Score {
+	values := questionValues(quiz, true)
+	return sumPoints(quiz, values, graded, 0, elapsed)
+}
+
+// negativeMarking is weighted scoring where wrong answers cost points. Unanswered
+// questions are neither rewarded nor penalised.
+type negativeMarking struct{}
+
+func (negativeMarking) Score(quiz *model.Quiz, graded []GradedQuestion, elapsed time.Duration) Score {
+	penalty := penaltyOrDefault(quiz.Scoring.Penalty, defaultPenalty)
+	values := questionValues(quiz, true)
+	return sumPoints(quiz, values, graded, penalty, elapsed)
+}
+
+// gradeAnswers grades every question of the quiz and scores the attempt with the
+// quiz's strategy. Answers are keyed by question index, and so are the hints revealed.
+func gradeAnswers(quiz *model.Quiz, answers map[string]any, hints map[int]int, elapsed time.Duration) ([]model.QuestionResult, Score, int) {
+	correctCount := 0
+	graded := make([]GradedQuestion, len(quiz.Questions))
+	results := make([]model.QuestionResult, 0, len(quiz.Questions))
+	for i := range quiz.Questions {
+		q := &quiz.Questions[i]
+		result := model.QuestionResult{
+			QuestionID:    q.ID,
+			Index:         i,
+			Type:          q.Kind(),
+			Text:          q.Text,
+			Options:       q.Options,
+			CorrectAnswer: q.CorrectAnswer(),
+			Explanation:   q.Explanation,
+			Reference:     q.Reference,
+			HintsUsed:     hints[i],
+		}
+		graded[i].Hints = hints[i]
+		if answer, ok := answers[strconv.Itoa(i)]; ok && answer != nil {
+			result.Answer = answer
+			result.Credit = q.Credit(answer)
+			if result.Credit < 1 && !quiz.Scoring.PartialCredit {
+				result.Credit = 0
+			}
+			result.Correct = result.Credit == 1
+			if result.Correct {
+				correctCount++
+			}
+			graded[i].Answered = true
+			graded[i].Credit = result.Credit
+		}
+		results = append(results, result)
+	}
+
+	score := scoringFor(quiz).Score(quiz, graded, elapsed)
+	for i := range results {
+		results[i].Points = score.QuestionPoints[i]
+	}
+	return results, score, correctCount
+}
+
+// questionValues returns how many points each question is worth.
+func questionValues(quiz *model.Quiz, weighted bool) []float64 {
+	values := make([]float64, len(quiz.Questions))
+	if len(quiz.Questions) == 0 {
+		return values
+	}
+
+	total := 0.0
+	for i := range quiz.Questions {
+		w := 1.0
+		if weighted {
+			w = quiz.Questions[i].QuestionWeight()
+		}
+		values[i] = w
+		total += w
+	}
+	for i := range values {
+		values[i] = float64(quiz.Points) * values[i] / total
+	}
+	return values
+}
+
+// sumPoints adds up the question values earned. Hints are paid for out of what
+// their question earns, so they never cost more than the question is worth.
+func sumPoints(quiz *model.Quiz, values []float64, graded []GradedQuestion, penalty float64, elapsed time.Duration) Score {
+	hintPenalty := penaltyOrDefault(quiz.Scoring.HintPenalty, defaultHintPenalty)
+
+	score := Score{QuestionPoints: make([]float64, len(values))}
+	earned := 0.0
+	for i, value := range values {
+		if i >= len(graded) || !graded[i].Answered {
+			continue
+		}
+		points := value * graded[i].Credit
+		if graded[i].Credit == 0 {
+			points = -value * penalty
+		} else if graded[i].Hints > 0 {
+			points = math.Max(0, points-value*hintPenalty*float64(graded[i].Hints))
+		}
+		score.QuestionPoints[i] = points
+		earned += points
+	}
+	earned = math.Max(0, earned)
+
+	if quiz.Points > 0 {
+		score.Percentage = int(math.Min(100, earned*100/float64(quiz.Points)))
+	}
+	score.SpeedBonus = speedBonus(quiz, earned, elapsed)
+	score.Points = int(earned) + score.SpeedBonus
+	return score
+}
+
+// speedBonus rewards finishing a timed quiz early, in proportion to the time left
+// and to the points earned so that blank submissions don't collect it.
+func speedBonus(quiz *model.Quiz, earned float64, elapsed time.Duration) int {
+	if quiz.Scoring.SpeedBonus <= 0 || quiz.TimeLimit <= 0 || quiz.Points <= 0 {
+		return 0
+	}
+	limit := time.Duration(quiz.TimeLimit) * time.Second
+	remaining := limit - elapsed
+	if remaining <= 0 {
+		return 0
+	}
+	return int(float64(quiz.Scoring.SpeedBonus) * remaining.Seconds() / limit.Seconds() * earned / float64(quiz.Points))
+}
+
+// penaltyOrDefault returns the configured penalty, or def when it isn't set.
+func penaltyOrDefault(penalty *float64, def float64) float64 {
+	if penalty == nil {
+		return def
+	}
+	return *penalty
+}
+
+// validateScoring rejects unknown strategies and out of range settings. Question
+// weights are checked with the shape of the questions.
+func validateScoring(verr *ValidationError, cfg model.ScoringConfig) {
+	if _, ok := scoringStrategies[cfg.Strategy]; !ok && cfg.Strategy != "" {
+		verr.add("scoring.strategy", fmt.Sprintf("%q is not a known strategy", cfg.Strategy))
+	}
+	if p := cfg.Penalty; p != nil && (*p < 0 || *p > 1) {
+		verr.add("scoring.penalty", "must be between 0 and 1")
+	}
+	if p := cfg.HintPenalty; p != nil && (*p < 0 || *p > 1) {
+		verr.add("scoring.hint_penalty", "must be between 0 and 1")
+	}
+	if cfg.SpeedBonus < 0 {
+		verr.add("scoring.speed_bonus", "can't be negative")
+	}
+}
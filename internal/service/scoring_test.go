@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func scoringQuiz(scoring model.ScoringConfig) *model.Quiz {
+	return &model.Quiz{
+		Points:  100,
+		Scoring: scoring,
+		Questions: []model.Question{
+			{Text: "a", Options: []string{"x", "y"}, Answer: 0},
+			{Text: "b", Options: []string{"x", "y"}, Answer: 0},
+		},
+	}
+}
+
+func TestNegativeMarkingPenalty(t *testing.T) {
+	tests := []struct {
+		name    string
+		penalty *float64
+		want    float64 // points of the wrong answer
+	}{
+		{"unset uses the default", nil, -50 * defaultPenalty},
+		{"explicit zero costs nothing", ptr(0.0), 0},
+		{"explicit penalty", ptr(0.5), -25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz := scoringQuiz(model.ScoringConfig{Strategy: model.ScoreNegativeMarked, Penalty: tt.penalty})
+			results, _, _ := gradeAnswers(quiz, map[string]any{"0": 0, "1": 1}, nil, 0)
+			if results[0].Points != 50 {
+				t.Errorf("correct answer got %v points, want 50", results[0].Points)
+			}
+			if results[1].Points != tt.want {
+				t.Errorf("wrong answer got %v points, want %v", results[1].Points, tt.want)
+			}
+		})
+	}
+}
+
+func TestHintPenalty(t *testing.T) {
+	tests := []struct {
+		name        string
+		hintPenalty *float64
+		hints       int
+		want        float64
+	}{
+		{"unset uses the default", nil, 1, 50 * (1 - defaultHintPenalty)},
+		{"explicit zero makes hints free", ptr(0.0), 2, 50},
+		{"explicit penalty per hint", ptr(0.2), 2, 30},
+		{"never below zero", ptr(0.6), 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz := scoringQuiz(model.ScoringConfig{HintPenalty: tt.hintPenalty})
+			results, _, _ := gradeAnswers(quiz, map[string]any{"0": 0}, map[int]int{0: tt.hints}, 0)
+			if results[0].Points != tt.want {
+				t.Errorf("got %v points, want %v", results[0].Points, tt.want)
+			}
+			if results[0].HintsUsed != tt.hints {
+				t.Errorf("got %d hints used, want %d", results[0].HintsUsed, tt.hints)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// snippetContext is how many bytes of text are kept around the first match.
+	snippetContext      = 60
+	maxQuestionSnippets = 3
+)
+
+// SearchResult is a quiz matched by a search, with highlighted snippets keyed
+// by field (title, category, description, questions).
+type SearchResult struct {
+	model.QuizSummary
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// SearchQuizzes runs a relevance-ranked full-text search over the catalog.
+func (s *QuizService) SearchQuizzes(ctx context.Context, actor Actor, text string, page int64, limit int64) ([]SearchResult, int64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, 0, fmt.Errorf("%w: empty search", ErrInvalidQuery)
+	}
+
+	hits, total, err := s.quizRepo.Search(ctx, text, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var completed []primitive.ObjectID
+	if !actor.UserID.IsZero() {
+		if user, err := s.userRepo.FindByID(ctx, actor.UserID); err == nil {
+			completed = user.CompletedQuizIDs
+		}
+	}
+
+	tier := userTier(ctx, s.subscriptions, actor.UserID)
+	marker := highlighter(text)
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		hit.Attempted = slices.Contains(completed, hit.ID)
+		hit.Locked = isLocked(actor, tier, &hit.Quiz)
+		result := SearchResult{
+			QuizSummary: hit.Summary(),
+			Score:       hit.Score,
+			Highlights:  make(map[string][]string),
+		}
+		if marker != nil {
+			for field, value := range map[string]string{"title": hit.Title, "category": hit.Category, "description": hit.Description} {
+				if snippet, ok := highlight(marker, value); ok {
+					result.Highlights[field] = []string{snippet}
+				}
+			}
+			// Locked quizzes don't give their questions away
+			for _, q := range hit.Questions {
+				if hit.Locked || len(result.Highlights["questions"]) == maxQuestionSnippets {
+					break
+				}
+				if snippet, ok := highlight(marker, q.Text); ok {
+					result.Highlights["questions"] = append(result.Highlights["questions"], snippet)
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, total, nil
+}
+
+// highlighter matches the words of a search case insensitively. Only matches at
+// the start of a word are highlighted (see wordMatches), so that stemmed matches
+// like "run" in "running" are marked too. Negated terms are skipped. It returns
+// nil when nothing can be highlighted.
+func highlighter(text string) *regexp.Regexp {
+	var terms []string
+	for _, field := range strings.Fields(text) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		term := regexp.QuoteMeta(strings.ToLower(strings.Trim(field, "\"'.,;:!?()[]{}")))
+		if term != "" && !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)(` + strings.Join(terms, "|") + `)`)
+}
+
+// wordMatches returns the byte ranges of the matches that start a word. Word
+// boundaries are found on Unicode letters and digits, as \b only knows ASCII.
+func wordMatches(marker *regexp.Regexp, text string) [][2]int {
+	var matches [][2]int
+	for i := 0; i < len(text); {
+		loc := marker.FindStringIndex(text[i:])
+		if loc == nil {
+			break
+		}
+		from, to := i+loc[0], i+loc[1]
+		if prev, _ := utf8.DecodeLastRuneInString(text[:from]); from > 0 && isWordRune(prev) {
+			// inside a word, look again from the next character
+			_, size := utf8.DecodeRuneInString(text[from:])
+			i = from + size
+			continue
+		}
+		matches = append(matches, [2]int{from, to})
+		i = to
+	}
+	return matches
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// highlight wraps the matches of text in <mark> tags. Long text is cut down to a
+// snippet around the first match. The text is user content, so everything else
+// in the snippet is HTML-escaped.
+func highlight(marker *regexp.Regexp, text string) (string, bool) {
+	matches := wordMatches(marker, text)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start, end := matches[0][0]-snippetContext, matches[0][1]+2*snippetContext
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(text) {
+		end, suffix = len(text), ""
+	}
+	// don't cut through a multi-byte character
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	pos := start
+	for _, m := range matches {
+		if m[1] > end {
+			break // cut off by the snippet
+		}
+		b.WriteString(html.EscapeString(text[pos:m[0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[m[0]:m[1]]) + "</mark>")
+		pos = m[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	b.WriteString(suffix)
+	return b.String(), true
+}
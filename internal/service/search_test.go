@@ -0,0 +1,26 @@
+package service
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name, search, text string
+		want               string
+		ok                 bool
+	}{
+		{"word starts", "run", "Running and outrun", "<mark>Run</mark>ning and outrun", true},
+		{"no match inside words", "run", "outrun", "", false},
+		{"escapes the text", "script", `<script>alert("x")</script>`, `&lt;<mark>script</mark>&gt;alert(&#34;x&#34;)&lt;/<mark>script</mark>&gt;`, true},
+		{"escapes special characters in the match", "a&b", "tom a&b", "tom <mark>a&amp;b</mark>", true},
+		{"unicode word boundaries", "über", "Fragen über Über und darüber", "Fragen <mark>über</mark> <mark>Über</mark> und darüber", true},
+		{"non latin scripts", "мир", "Привет, мир! Примир", "Привет, <mark>мир</mark>! Примир", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := highlight(highlighter(tt.search), tt.text)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("got %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"github.com/sachinggsingh/quiz/internal/repo"
+	"github.com/sachinggsingh/quiz/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrShareLinkNotFound = errors.New("share link not found")
+	ErrShareLinkInvalid  = errors.New("share link is invalid, expired or revoked")
+)
+
+type ShareService struct {
+	shareRepo repo.ShareLinkRepo
+	quizRepo  repo.QuizRepo
+}
+
+func NewShareService(shareRepo repo.ShareLinkRepo, quizRepo repo.QuizRepo) *ShareService {
+	return &ShareService{
+		shareRepo: shareRepo,
+		quizRepo:  quizRepo,
+	}
+}
+
+// CreateLink creates a share link to a quiz the actor manages. Public quizzes can
+// be shared too, the link keeps working if they are made unlisted later.
+func (s *ShareService) CreateLink(ctx context.Context, actor Actor, quizID primitive.ObjectID, label string, expiresAt *time.Time) (*model.ShareLink, error) {
+	quiz, err := s.findManagedQuiz(ctx, actor, quizID)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidQuiz)
+	}
+
+	link := &model.ShareLink{
+		QuizID:    quiz.ID,
+		CreatedBy: actor.UserID,
+		Label:     label,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.shareRepo.Create(ctx, link); err != nil {
+		return nil, err
+	}
+	if err := s.sign(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// ListLinks returns the share links of a quiz the actor manages, newest first.
+// Only active links carry their token.
+func (s *ShareService) ListLinks(ctx context.Context, actor Actor, quizID primitive.ObjectID) ([]model.ShareLink, error) {
+	quiz, err := s.findManagedQuiz(ctx, actor, quizID)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.shareRepo.FindByQuiz(ctx, quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range links {
+		if links[i].Active(now) {
+			if err := s.sign(&links[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return links, nil
+}
+
+// RevokeLink stops a share link from opening its quiz. Attempts already started
+// through it can still be submitted.
+func (s *ShareService) RevokeLink(ctx context.Context, actor Actor, quizID primitive.ObjectID, linkID primitive.ObjectID) error {
+	quiz, err := s.findManagedQuiz(ctx, actor, quizID)
+	if err != nil {
+		return err
+	}
+	if err := s.shareRepo.Revoke(ctx, linkID, quiz.ID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrShareLinkNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// CheckVisibility lets the actor through to a quiz kept out of the catalog if
+// they manage or review it, or if they hold an active share link to it. Without
+// a link the quiz is reported as not found, so its existence isn't leaked.
+func (s *ShareService) CheckVisibility(ctx context.Context, actor Actor, quiz *model.Quiz, token string) error {
+	if quiz.Listed() || canManageQuiz(actor, quiz) || isReviewer(actor) {
+		return nil
+	}
+	if token == "" {
+		return ErrQuizNotFound
+	}
+	if quiz.QuizVisibility() == model.VisibilityPrivate && actor.UserID.IsZero() {
+		return fmt.Errorf("%w: private quizzes need a signed-in user", ErrShareLinkInvalid)
+	}
+
+	linkHex, quizHex, err := utils.ParseShareToken(token)
+	if err != nil || quizHex != quiz.ID.Hex() {
+		return ErrShareLinkInvalid
+	}
+	linkID, err := primitive.ObjectIDFromHex(linkHex)
+	if err != nil {
+		return ErrShareLinkInvalid
+	}
+	link, err := s.shareRepo.FindByID(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrShareLinkInvalid
+		}
+		return err
+	}
+	if link.QuizID != quiz.ID || !link.Active(time.Now()) {
+		return ErrShareLinkInvalid
+	}
+	return nil
+}
+
+func (s *ShareService) findManagedQuiz(ctx context.Context, actor Actor, id primitive.ObjectID) (*model.Quiz, error) {
+	quiz, err := s.quizRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrQuizNotFound
+		}
+		return nil, err
+	}
+	if !canManageQuiz(actor, quiz) {
+		return nil, ErrNotQuizOwner
+	}
+	return quiz, nil
+}
+
+func (s *ShareService) sign(link *model.ShareLink) error {
+	token, err := utils.GenerateShareToken(link.ID.Hex(), link.QuizID.Hex(), link.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	link.Token = token
+	return nil
+}
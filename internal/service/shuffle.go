@@ -0,0 +1,82 @@
+package service
+
+import (
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+// Streams of an attempt's seed, so that the bank draw and the shuffle don't
+// depend on each other.
+const (
+	drawStream    = 1
+	shuffleStream = 2
+)
+
+func seededRand(seed int64, stream uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), stream))
+}
+
+// shuffleAttempt draws the question and option order of the attempt from its
+// seed. Quizzes that keep their order are served as authored.
+func shuffleAttempt(attempt *model.Attempt, questions []model.Question, keepOrder bool) {
+	attempt.QuestionOrder, attempt.OptionOrders = nil, nil
+	if keepOrder {
+		return
+	}
+
+	rng := seededRand(attempt.Seed, shuffleStream)
+	attempt.QuestionOrder = rng.Perm(len(questions))
+	attempt.OptionOrders = make([][]int, len(questions))
+	for i := range questions {
+		if questions[i].ShufflesOptions() {
+			attempt.OptionOrders[i] = rng.Perm(len(questions[i].Options))
+		}
+	}
+}
+
+// canonicalAnswers maps answers keyed by shown position, given on shown option
+// indexes, to the quiz's question and option indexes.
+func canonicalAnswers(attempt *model.Attempt, questions []model.Question, answers map[string]any) map[string]any {
+	canonical := make(map[string]any, len(answers))
+	for i := range questions {
+		answer, ok := answers[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+		q := attempt.QuestionAt(i)
+		canonical[strconv.Itoa(q)] = questions[q].CanonicalAnswer(answer, attempt.OptionOrder(q))
+	}
+	return canonical
+}
+
+// canonicalHints maps the hints revealed per shown position to quiz question indexes.
+func canonicalHints(attempt *model.Attempt, questions []model.Question) map[int]int {
+	hints := make(map[int]int, len(attempt.HintsUsed))
+	for i := range questions {
+		if used := attempt.HintsUsed[strconv.Itoa(i)]; used > 0 {
+			hints[attempt.QuestionAt(i)] = used
+		}
+	}
+	return hints
+}
+
+// reviewInAttemptOrder rearranges results graded in quiz order the way the
+// attempt showed the questions, with the user's own answers and the correct
+// answers pointing at the options as they were shown.
+func reviewInAttemptOrder(attempt *model.Attempt, questions []model.Question, results []model.QuestionResult, answers map[string]any) []model.QuestionResult {
+	displayed := attempt.Displayed(questions)
+	review := make([]model.QuestionResult, 0, len(results))
+	for i := range displayed {
+		result := results[attempt.QuestionAt(i)]
+		result.Index = i
+		result.Options = displayed[i].Options
+		result.CorrectAnswer = displayed[i].CorrectAnswer()
+		if answer, ok := answers[strconv.Itoa(i)]; ok && answer != nil {
+			result.Answer = answer
+		}
+		review = append(review, result)
+	}
+	return review
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+func shuffleQuiz() *model.Quiz {
+	return &model.Quiz{
+		Points: 60,
+		Questions: []model.Question{
+			{Type: model.QuestionSingleChoice, Text: "Capital of France?", Options: []string{"Paris", "Lyon", "Nice", "Lille"}, Answer: 0,
+				Hints: []model.Hint{{Type: model.HintEliminate, Option: 2}, {Type: model.HintClue, Text: "On the Seine"}}},
+			{Type: model.QuestionMultiSelect, Text: "Pick the primes", Options: []string{"2", "4", "5", "9", "11"}, Answers: []int{0, 2, 4}},
+			{Type: model.QuestionTrueFalse, Text: "Go has generics", Options: []string{"True", "False"}, Answer: 0},
+			{Type: model.QuestionNumeric, Text: "Value of pi?", NumericAnswer: 3.14, Tolerance: 0.01},
+			{Type: model.QuestionOrdering, Text: "Sort ascending", Options: []string{"3", "1", "4", "2"}, Order: []int{1, 3, 0, 2}},
+			{Type: model.QuestionShortText, Text: "Go's mascot?", AcceptedAnswers: []string{"gopher"}, Fuzzy: true},
+		},
+	}
+}
+
+// shownIndexes returns where the options with the given texts are shown.
+func shownIndexes(q model.Question, texts ...string) []int {
+	indexes := make([]int, len(texts))
+	for i, text := range texts {
+		indexes[i] = slices.Index(q.Options, text)
+	}
+	return indexes
+}
+
+// answerByText answers every question the same way whatever the option order,
+// partly right and partly wrong.
+func answerByText(q model.Question) any {
+	switch q.Kind() {
+	case model.QuestionSingleChoice:
+		return shownIndexes(q, "Lyon")[0]
+	case model.QuestionMultiSelect:
+		return shownIndexes(q, "2", "5", "9")
+	case model.QuestionTrueFalse:
+		return shownIndexes(q, "True")[0]
+	case model.QuestionNumeric:
+		return 3.2
+	case model.QuestionOrdering:
+		return shownIndexes(q, "1", "2", "4", "3")
+	case model.QuestionShortText:
+		return "gophr"
+	}
+	return nil
+}
+
+func answerCorrectly(q model.Question) any {
+	return q.CorrectAnswer()
+}
+
+// playAttempt answers the questions the attempt shows, with hints on the
+// capital question, and grades them like a submission.
+func playAttempt(quiz *model.Quiz, attempt *model.Attempt, answer func(model.Question) any) ([]model.QuestionResult, Score) {
+	answers := map[string]any{}
+	attempt.HintsUsed = map[string]int{}
+	for i, q := range attempt.Displayed(quiz.Questions) {
+		answers[strconv.Itoa(i)] = answer(q)
+		if q.Text == "Capital of France?" {
+			attempt.HintsUsed[strconv.Itoa(i)] = 2
+		}
+	}
+	results, score, _ := gradeAnswers(quiz, canonicalAnswers(attempt, quiz.Questions, answers), canonicalHints(attempt, quiz.Questions), 0)
+	return results, score
+}
+
+func TestShuffledAttemptsGradeLikeTheQuiz(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer func(model.Question) any
+	}{
+		{"correct answers", answerCorrectly},
+		{"answers by option text", answerByText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz := shuffleQuiz()
+			plain := &model.Attempt{}
+			shuffleAttempt(plain, quiz.Questions, true)
+			want, wantScore := playAttempt(quiz, plain, tt.answer)
+
+			reordered := false
+			for seed := range int64(20) {
+				attempt := &model.Attempt{Seed: seed}
+				shuffleAttempt(attempt, quiz.Questions, false)
+				reordered = reordered || !slices.IsSorted(attempt.QuestionOrder)
+
+				got, score := playAttempt(quiz, attempt, tt.answer)
+				for i := range want {
+					if got[i].Correct != want[i].Correct || got[i].Credit != want[i].Credit || got[i].Points != want[i].Points || got[i].HintsUsed != want[i].HintsUsed {
+						t.Errorf("seed %d, %s: got correct %v credit %v points %v hints %d, want %v %v %v %d", seed, quiz.Questions[i].Kind(),
+							got[i].Correct, got[i].Credit, got[i].Points, got[i].HintsUsed, want[i].Correct, want[i].Credit, want[i].Points, want[i].HintsUsed)
+					}
+				}
+				if !reflect.DeepEqual(score, wantScore) {
+					t.Errorf("seed %d: score %+v, want %+v", seed, score, wantScore)
+				}
+			}
+			if !reordered {
+				t.Error("no seed changed the question order")
+			}
+		})
+	}
+}
+
+func TestShuffleAttemptIsSeeded(t *testing.T) {
+	quiz := shuffleQuiz()
+	a, b := &model.Attempt{Seed: 42}, &model.Attempt{Seed: 42}
+	shuffleAttempt(a, quiz.Questions, false)
+	shuffleAttempt(b, quiz.Questions, false)
+	if !slices.Equal(a.QuestionOrder, b.QuestionOrder) {
+		t.Errorf("question orders %v and %v differ for the same seed", a.QuestionOrder, b.QuestionOrder)
+	}
+	for i, q := range quiz.Questions {
+		if !slices.Equal(a.OptionOrders[i], b.OptionOrders[i]) {
+			t.Errorf("%s option orders %v and %v differ for the same seed", q.Kind(), a.OptionOrders[i], b.OptionOrders[i])
+		}
+		if q.ShufflesOptions() != (a.OptionOrders[i] != nil) {
+			t.Errorf("%s questions got option order %v", q.Kind(), a.OptionOrders[i])
+		}
+	}
+}
+
+func TestCanonicalHints(t *testing.T) {
+	questions := shuffleQuiz().Questions[:3]
+	attempt := &model.Attempt{
+		QuestionOrder: []int{2, 0, 1},
+		HintsUsed:     map[string]int{"1": 2, "2": 0, "7": 1},
+	}
+	// Position 1 shows quiz question 0; unused counts and unknown positions are dropped
+	if got, want := canonicalHints(attempt, questions), map[int]int{0: 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -2,19 +2,33 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"errors"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/sachinggsingh/quiz/config"
 	"github.com/sachinggsingh/quiz/internal/model"
 	"github.com/sachinggsingh/quiz/internal/repo"
 	"github.com/sachinggsingh/quiz/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// maxStatsRetries bounds how many times a stats update is retried when other
+// submissions of the same user keep winning the race.
+const maxStatsRetries = 10
+
+var (
+	ErrInvalidRole   = errors.New("invalid role")
+	ErrUserNotFound  = errors.New("user not found")
+	ErrStatsConflict = errors.New("too many concurrent submissions, try again")
+)
+
 type UserService struct {
 	repo repo.UserRepo
 }
@@ -28,39 +42,56 @@ func (s *UserService) GetProfile(ctx context.Context, id primitive.ObjectID) (*m
 }
 
 func (s *UserService) SubmitQuizResult(ctx context.Context, userID primitive.ObjectID, quizID primitive.ObjectID, score int) error {
-	user, err := s.repo.FindByID(ctx, userID)
-	if err != nil {
-		return err
-	}
-
-	// Check if already completed
-	alreadyCompleted := slices.Contains(user.CompletedQuizIDs, quizID)
-
-	newTotalScore := user.Score
-	newAverageScore := user.AverageScore
-
-	if alreadyCompleted {
-		return errors.New("quiz already attempted")
-	}
-
-	countTotalQuizzes := len(user.CompletedQuizIDs)
-
-	newTotalScore += score
-	// Re-calculate average: (old_avg * old_count + new_score) / new_count
-	newAverageScore = (user.AverageScore*float64(countTotalQuizzes) + float64(score)) / float64(countTotalQuizzes+1)
-	user.CompletedQuizIDs = append(user.CompletedQuizIDs, quizID)
-
-	// Simplified streak: increment on every submission
-	newStreak := user.Streak + 1
-
-	// Update Activity
-	today := time.Now().Format(time.DateOnly)
-	if user.Activity == nil {
-		user.Activity = make(map[string]int)
-	}
-	user.Activity[today]++
+	_, err := updateUserStats(ctx, s.repo, userID, func(user *model.User) error {
+		// Check if already completed
+		if slices.Contains(user.CompletedQuizIDs, quizID) {
+			return errors.New("quiz already attempted")
+		}
+
+		countTotalQuizzes := len(user.CompletedQuizIDs)
+
+		user.Score += score
+		// Re-calculate average: (old_avg * old_count + new_score) / new_count
+		user.AverageScore = (user.AverageScore*float64(countTotalQuizzes) + float64(score)) / float64(countTotalQuizzes+1)
+		user.CompletedQuizIDs = append(user.CompletedQuizIDs, quizID)
+
+		// Simplified streak: increment on every submission
+		user.Streak++
+
+		// Update Activity
+		today := time.Now().Format(time.DateOnly)
+		if user.Activity == nil {
+			user.Activity = make(map[string]int)
+		}
+		user.Activity[today]++
+		return nil
+	})
+	return err
+}
 
-	return s.repo.UpdateStats(ctx, userID, newTotalScore, countTotalQuizzes, newAverageScore, newStreak, user.Activity, user.CompletedQuizIDs)
+// updateUserStats applies change to a fresh copy of the user and stores the result
+// unless another stats update landed in between, in which case it starts over from
+// the new stats. change runs on every try, so the checks it makes always see the
+// latest stats. Errors returned by change abort the update.
+func updateUserStats(ctx context.Context, users repo.UserRepo, userID primitive.ObjectID, change func(user *model.User) error) (*model.User, error) {
+	for range maxStatsRetries {
+		user, err := users.FindByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		version := user.StatsVersion
+		if err := change(user); err != nil {
+			return nil, err
+		}
+		err = users.UpdateStats(ctx, user, version)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+	return nil, ErrStatsConflict
 }
 
 // creating the user and hashing the password
@@ -79,6 +110,10 @@ func (s *UserService) CreateUser(ctx context.Context, name, email, password stri
 		Name:     name,
 		Email:    email,
 		Password: string(hashedPassword),
+		Role:     model.RolePlayer,
+	}
+	if isBootstrapAdmin(email) {
+		user.Role = model.RoleAdmin
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
@@ -98,8 +133,16 @@ func (s *UserService) Login(ctx context.Context, email string, password string)
 		return "", "", errors.New("invalid credentials")
 	}
 
+	// Users listed in ADMIN_EMAILS are promoted on login, so the first admin can be bootstrapped
+	if isBootstrapAdmin(email) && user.UserRole() != model.RoleAdmin {
+		if err := s.repo.UpdateRole(ctx, user.UserId, model.RoleAdmin); err != nil {
+			return "", "", err
+		}
+		user.Role = model.RoleAdmin
+	}
+
 	// generateToken
-	access_Token, refresh_Token, err := utils.GenerateToken(user.UserId.Hex(), email)
+	access_Token, refresh_Token, err := utils.GenerateToken(user.UserId.Hex(), email, string(user.UserRole()))
 	if err != nil {
 		return "", "", err
 	}
@@ -148,6 +191,38 @@ func (s *UserService) RefreshToken(ctx context.Context, refreshTokenStr string)
 		return "", errors.New("refresh token mismatch")
 	}
 
-	access_Token, _, err := utils.GenerateToken(userIDHex, email)
+	// The role is read from the DB so role changes apply on the next refresh
+	access_Token, _, err := utils.GenerateToken(userIDHex, email, string(user.UserRole()))
 	return access_Token, err
 }
+
+// UpdateRole changes a user's role. A demotion takes effect on the user's next
+// request, a promotion on their next login or token refresh.
+func (s *UserService) UpdateRole(ctx context.Context, userID primitive.ObjectID, role model.Role) error {
+	if !slices.Contains(model.Roles, role) {
+		return fmt.Errorf("%w %q", ErrInvalidRole, role)
+	}
+	err := s.repo.UpdateRole(ctx, userID, role)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// CurrentRole returns the role stored for the user, used to check the role
+// claimed by access tokens.
+func (s *UserService) CurrentRole(ctx context.Context, userID string) (string, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return string(user.UserRole()), nil
+}
+
+func isBootstrapAdmin(email string) bool {
+	return slices.Contains(config.LoadEnv().ADMIN_EMAILS, strings.ToLower(email))
+}
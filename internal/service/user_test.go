@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func statsUsers() (*fakeUserRepo, primitive.ObjectID) {
+	id := primitive.NewObjectID()
+	return &fakeUserRepo{users: map[primitive.ObjectID]model.User{id: {UserId: id, Score: 10, StatsVersion: 3}}}, id
+}
+
+func addPoints(points int) func(*model.User) error {
+	return func(u *model.User) error {
+		u.Score += points
+		return nil
+	}
+}
+
+func TestUpdateUserStatsRetriesOnConflict(t *testing.T) {
+	users, id := statsUsers()
+	conflicts := 2
+	users.beforeUpdate = func(r *fakeUserRepo) {
+		if conflicts > 0 {
+			conflicts--
+			r.bumpScore(id, 5)
+		}
+	}
+
+	calls := 0
+	user, err := updateUserStats(context.Background(), users, id, func(u *model.User) error {
+		calls++
+		return addPoints(1)(u)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("change ran %d times, want 3", calls)
+	}
+	// Both concurrent updates are kept and ours lands on top of them
+	if stored := users.users[id]; stored.Score != 21 || stored.StatsVersion != 6 {
+		t.Errorf("stored score %d at version %d, want 21 at version 6", stored.Score, stored.StatsVersion)
+	}
+	if user.Score != 21 || user.StatsVersion != 6 {
+		t.Errorf("returned score %d at version %d, want 21 at version 6", user.Score, user.StatsVersion)
+	}
+}
+
+func TestUpdateUserStatsGivesUp(t *testing.T) {
+	users, id := statsUsers()
+	users.beforeUpdate = func(r *fakeUserRepo) { r.bumpScore(id, 5) }
+
+	_, err := updateUserStats(context.Background(), users, id, addPoints(1))
+	if !errors.Is(err, ErrStatsConflict) {
+		t.Fatalf("got %v, want ErrStatsConflict", err)
+	}
+	if users.updates != maxStatsRetries {
+		t.Errorf("tried %d times, want %d", users.updates, maxStatsRetries)
+	}
+}
+
+func TestUpdateUserStatsChangeError(t *testing.T) {
+	users, id := statsUsers()
+	users.beforeUpdate = func(r *fakeUserRepo) { r.bumpScore(id, 5) }
+	tries := 0
+	rejected := errors.New("rejected")
+
+	// the check passes on the first read and fails once it sees the concurrent update
+	_, err := updateUserStats(context.Background(), users, id, func(u *model.User) error {
+		tries++
+		if u.Score > 10 {
+			return rejected
+		}
+		return addPoints(1)(u)
+	})
+	if !errors.Is(err, rejected) {
+		t.Fatalf("got %v, want the change's error", err)
+	}
+	if tries != 2 || users.updates != 1 {
+		t.Errorf("change ran %d times and %d updates were tried, want 2 and 1", tries, users.updates)
+	}
+	if stored := users.users[id]; stored.Score != 15 {
+		t.Errorf("stored score is %d, want only the concurrent update's 15", stored.Score)
+	}
+}
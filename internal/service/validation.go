@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+// ValidationError lists every problem found in a quiz or question. It wraps
+// ErrInvalidQuiz, so callers checking for that keep working.
+type ValidationError struct {
+	Errors []model.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return ErrInvalidQuiz.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidQuiz
+}
+
+func (e *ValidationError) add(field, message string) {
+	e.Errors = append(e.Errors, model.FieldError{Field: field, Message: message})
+}
+
+// addQuestionError adds a question validation error, located under the path
+// of the question.
+func (e *ValidationError) addQuestionError(question string, err error) {
+	if err == nil {
+		return
+	}
+	var fe *model.FieldError
+	if errors.As(err, &fe) {
+		e.add(joinPath(question, fe.Field), fe.Message)
+		return
+	}
+	e.add(question, err.Error())
+}
+
+// has reports whether a problem was found in field or one of its subfields.
+func (e *ValidationError) has(field string) bool {
+	for _, fe := range e.Errors {
+		if fe.Field == field || strings.HasPrefix(fe.Field, field+".") || strings.HasPrefix(fe.Field, field+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// orNil returns the error if it found anything.
+func (e *ValidationError) orNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func joinPath(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
+
+// fieldMessages renders the failed validation tags.
+var fieldMessages = map[string]func(param string) string{
+	"required":         func(string) string { return "is required" },
+	"notblank":         func(string) string { return "can't be blank" },
+	"gt":               func(p string) string { return "must be greater than " + p },
+	"gte":              func(p string) string { return "can't be less than " + p },
+	"distinct_options": func(string) string { return "must not contain duplicates" },
+	"option_index":     func(string) string { return "out of range" },
+	"question_type":    func(string) string { return "is not a supported question type" },
+}
+
+var quizValidator = newQuizValidator()
+
+func newQuizValidator() *validator.Validate {
+	v := validator.New()
+	// Report fields by the names clients send
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterValidation("notblank", validators.NotBlank)
+	v.RegisterValidation("distinct_options", distinctOptions)
+	v.RegisterStructValidation(validateQuizStruct, model.Quiz{})
+	v.RegisterStructValidation(validateQuestionStruct, model.Question{})
+	return v
+}
+
+// structErrors checks the shape of a quiz or a question and returns every
+// problem found, named under prefix.
+func structErrors(v any, prefix string) []model.FieldError {
+	var errs validator.ValidationErrors
+	if !errors.As(quizValidator.Struct(v), &errs) {
+		return nil
+	}
+
+	fields := make([]model.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		// Drop the root struct name from the path
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		msg := "is invalid"
+		if render, ok := fieldMessages[fe.Tag()]; ok {
+			msg = render(fe.Param())
+		}
+		fields = append(fields, model.FieldError{Field: joinPath(prefix, field), Message: msg})
+	}
+	return fields
+}
+
+// questionErrors checks a single question, filling its type defaults, and
+// returns every problem found, named under prefix. The type checks only run on
+// questions of a valid shape.
+func questionErrors(q *model.Question, prefix string) []model.FieldError {
+	verr := &ValidationError{Errors: structErrors(q, prefix)}
+	if len(verr.Errors) == 0 {
+		verr.addQuestionError(prefix, prepareQuestion(q))
+	}
+	return verr.Errors
+}
+
+// validateQuizStruct requires questions unless the quiz draws them from the question bank.
+func validateQuizStruct(sl validator.StructLevel) {
+	quiz := sl.Current().Interface().(model.Quiz)
+	if len(quiz.Questions) == 0 && len(quiz.Rules) == 0 {
+		sl.ReportError(quiz.Questions, "questions", "Questions", "required", "")
+	}
+}
+
+// validateQuestionStruct checks that the answer key points at existing options.
+func validateQuestionStruct(sl validator.StructLevel) {
+	q := sl.Current().Interface().(model.Question)
+	inRange := func(i, options int) bool { return i >= 0 && i < options }
+
+	switch q.Kind() {
+	case model.QuestionSingleChoice:
+		if !inRange(q.Answer, len(q.Options)) {
+			sl.ReportError(q.Answer, "answer", "Answer", "option_index", "")
+		}
+	case model.QuestionTrueFalse:
+		// the options default to True/False
+		if !inRange(q.Answer, max(len(q.Options), 2)) {
+			sl.ReportError(q.Answer, "answer", "Answer", "option_index", "")
+		}
+	case model.QuestionMultiSelect:
+		for i, a := range q.Answers {
+			if !inRange(a, len(q.Options)) {
+				sl.ReportError(a, "answers["+strconv.Itoa(i)+"]", "Answers", "option_index", "")
+			}
+		}
+	case model.QuestionOrdering:
+		for i, o := range q.Order {
+			if !inRange(o, len(q.Options)) {
+				sl.ReportError(o, "order["+strconv.Itoa(i)+"]", "Order", "option_index", "")
+			}
+		}
+	case model.QuestionNumeric, model.QuestionShortText:
+	default:
+		sl.ReportError(q.Type, "type", "Type", "question_type", "")
+	}
+}
+
+// distinctOptions rejects options that only differ in case or surrounding spaces.
+func distinctOptions(fl validator.FieldLevel) bool {
+	options, ok := fl.Field().Interface().([]string)
+	if !ok {
+		return false
+	}
+	seen := make(map[string]bool, len(options))
+	for _, o := range options {
+		key := strings.ToLower(strings.TrimSpace(o))
+		if key == "" {
+			continue // blank options are reported on their own
+		}
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+	}
+	return true
+}
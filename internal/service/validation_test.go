@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+)
+
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+	names := make([]string, len(verr.Errors))
+	for i, fe := range verr.Errors {
+		names[i] = fe.Field
+	}
+	return names
+}
+
+func TestValidateQuizContentReportsEverything(t *testing.T) {
+	quiz := &model.Quiz{
+		Title:  "Mixed bag",
+		Points: 10,
+		Questions: []model.Question{
+			{Text: "fine", Options: []string{"a", "b"}, Answer: 1},
+			{Text: "no key", Type: model.QuestionMultiSelect, Options: []string{"a", "b"}},
+			{Text: "", Options: []string{"a", "b"}, Answer: 0},
+			{Text: "bad hint", Options: []string{"a", "b", "c"}, Answer: 0, Hints: []model.Hint{{Type: model.HintEliminate, Option: 0}}},
+		},
+		Rules:      []model.QuestionRule{{Count: 0}},
+		AccessTier: "gold",
+		Visibility: "hidden",
+		Retake:     model.RetakePolicy{Mode: model.RetakeLimited},
+		Scoring:    model.ScoringConfig{Penalty: ptr(2.0), SpeedBonus: -1},
+	}
+
+	got := fields(t, validateQuizContent(quiz))
+	want := []string{
+		"questions[2].text", // shape problems come first
+		"questions[1].answers",
+		"questions[3].hints[0].option",
+		"rules[0].count",
+		"access_tier",
+		"visibility",
+		"retake.max_attempts",
+		"scoring.penalty",
+		"scoring.speed_bonus",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got fields %v, want %v", got, want)
+	}
+	if !errors.Is(validateQuizContent(quiz), ErrInvalidQuiz) {
+		t.Error("validation errors must wrap ErrInvalidQuiz")
+	}
+}
+
+func TestValidateQuizContentValid(t *testing.T) {
+	quiz := &model.Quiz{
+		Title:     "True or false",
+		Points:    10,
+		Questions: []model.Question{{Text: "The sky is blue", Type: model.QuestionTrueFalse, Answer: 0}},
+	}
+	if err := validateQuizContent(quiz); err != nil {
+		t.Fatal(err)
+	}
+	if len(quiz.Questions[0].Options) != 2 {
+		t.Errorf("true_false options default to %v, want True and False", quiz.Questions[0].Options)
+	}
+}
+
+func TestPrepareBankQuestion(t *testing.T) {
+	question := &model.BankQuestion{Question: model.Question{Text: "Pick", Options: []string{"a", "b", ""}, Answer: 5, Weight: -1}}
+	got := fields(t, prepareBankQuestion(question))
+	want := []string{"question.options[2]", "question.weight", "question.answer"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got fields %v, want %v", got, want)
+	}
+
+	question = &model.BankQuestion{Question: model.Question{Text: "Order", Type: model.QuestionOrdering, Options: []string{"a", "b"}, Order: []int{0}}}
+	if got := fields(t, prepareBankQuestion(question)); !slices.Equal(got, []string{"question.order"}) {
+		t.Errorf("got fields %v, want the type check's question.order", got)
+	}
+}
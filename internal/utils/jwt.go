@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,10 +18,11 @@ const (
 )
 
 // GenerateToken creates a new JWT token for a given user ID and duration
-func GenerateToken(userId string, email string) (string, string, error) {
+func GenerateToken(userId string, email string, role string) (string, string, error) {
 	tokenClaims := jwt.MapClaims{
 		"user_id": userId,
 		"email":   email,
+		"role":    role,
 		"exp":     jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)), // 7 days, long-lived
 	}
 	refreshTokenClaims := jwt.MapClaims{
@@ -52,6 +54,47 @@ func TokenValidator(token string) (*jwt.Token, error) {
 	})
 }
 
+// shareKey signs share links. It differs from the session key so a share
+// token can never pass as an access token.
+func shareKey() []byte {
+	return []byte("share:" + config.LoadEnv().JWT_KEY)
+}
+
+// GenerateShareToken signs a share link of a quiz, expiring with the link if it has an expiry
+func GenerateShareToken(shareId string, quizId string, expiresAt *time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"share_id": shareId,
+		"quiz_id":  quizId,
+	}
+	if expiresAt != nil {
+		claims["exp"] = jwt.NewNumericDate(*expiresAt)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(shareKey())
+}
+
+// ParseShareToken verifies a share token and returns the share link and quiz it names
+func ParseShareToken(tokenString string) (string, string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return shareKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", fmt.Errorf("invalid share token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", fmt.Errorf("invalid share token claims")
+	}
+	shareId, _ := claims["share_id"].(string)
+	quizId, _ := claims["quiz_id"].(string)
+	if shareId == "" || quizId == "" {
+		return "", "", fmt.Errorf("invalid share token claims")
+	}
+	return shareId, quizId, nil
+}
+
 // SetCookie sets a secure HTTP-only cookie
 func SetCookie(w http.ResponseWriter, name, value string, maxAge int) {
 	env := config.LoadEnv()
@@ -115,6 +158,26 @@ func GetTokenFromRequest(r *http.Request) string {
 	return ""
 }
 
+// RoleLookup returns the role currently stored for a user.
+type RoleLookup func(ctx context.Context, userID string) (string, error)
+
+var roleLookup RoleLookup
+
+// UseRoleLookup makes the role of tokens claiming more than a player checked
+// against the stored user, so a demotion takes effect before the token expires.
+func UseRoleLookup(lookup RoleLookup) {
+	roleLookup = lookup
+}
+
+// VerifiedRole returns the role a request acts with. Player tokens are trusted
+// as is; privileged claims are replaced by the user's stored role.
+func VerifiedRole(ctx context.Context, userID string, claimed string) (string, error) {
+	if roleLookup == nil || claimed == "" || claimed == "player" {
+		return claimed, nil
+	}
+	return roleLookup(ctx, userID)
+}
+
 func Authenticate(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tokenString := GetTokenFromRequest(r)
@@ -139,12 +202,32 @@ func Authenticate(next http.HandlerFunc) http.HandlerFunc {
 			WriteError(w, http.StatusUnauthorized, "invalid token claims")
 			return
 		}
+		userID, _ := claims["user_id"].(string)
+		claimed, _ := claims["role"].(string)
+		role, err := VerifiedRole(r.Context(), userID, claimed)
+		if err != nil {
+			WriteError(w, http.StatusUnauthorized, "user not found")
+			return
+		}
 		r = r.WithContext(context.WithValue(r.Context(), "user_id", claims["user_id"]))
 		r = r.WithContext(context.WithValue(r.Context(), "email", claims["email"]))
+		r = r.WithContext(context.WithValue(r.Context(), "role", role))
 		next(w, r)
 	}
 }
 
+// RequireRoles authenticates the request and only lets it through if the
+// user's role is one of roles.
+func RequireRoles(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(roles, GetRole(r.Context())) {
+			WriteError(w, http.StatusForbidden, "insufficient permissions")
+			return
+		}
+		next(w, r)
+	})
+}
+
 func GetUserId(ctx context.Context) string {
 	if val := ctx.Value("user_id"); val != nil {
 		return val.(string)
@@ -152,6 +235,16 @@ func GetUserId(ctx context.Context) string {
 	return ""
 }
 
+// GetRole returns the role of the authenticated user, checked against the
+// stored user when the token claims a privileged one. Tokens issued before
+// roles existed carry none and are treated as players.
+func GetRole(ctx context.Context) string {
+	if role, ok := ctx.Value("role").(string); ok && role != "" {
+		return role
+	}
+	return "player"
+}
+
 func GetEmail(r *http.Request) string {
 	return r.Context().Value("email").(string)
 }
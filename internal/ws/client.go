@@ -35,6 +35,7 @@ type Client struct {
 	QuizID string
 	RoomID string
 	UserID string
+	Role   string
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -63,6 +64,10 @@ func (c *Client) ReadPump() {
 		// Try to parse as Message struct
 		var msg Message
 		if err := json.Unmarshal(message, &msg); err == nil && msg.Type != "" {
+			// Game messages are handled by the room's game loop and never broadcast
+			if room := c.Hub.GetRoom(c.RoomID); room != nil && room.Game != nil && room.Game.HandleMessage(c, msg) {
+				continue
+			}
 			// It's a structured message
 			msg.RoomID = c.RoomID
 			c.Hub.broadcast <- msg
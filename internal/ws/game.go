@@ -0,0 +1,431 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sachinggsingh/quiz/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Message types understood by the game engine. Anything else sent by a
+// client in a room keeps going through the regular room broadcast.
+const (
+	MsgStartGame = "START_GAME"
+	MsgAnswer    = "ANSWER"
+
+	MsgGameStarted    = "GAME_STARTED"
+	MsgQuestion       = "QUESTION"
+	MsgCountdown      = "COUNTDOWN"
+	MsgAnswerReceived = "ANSWER_RECEIVED"
+	MsgQuestionResult = "QUESTION_RESULT"
+	MsgGameOver       = "GAME_OVER"
+	MsgGameError      = "GAME_ERROR"
+)
+
+const (
+	defaultQuestionTime = 20 * time.Second
+	minQuestionTime     = 5 * time.Second
+	maxQuestionTime     = 120 * time.Second
+
+	// Time between the result of a question and the next question.
+	revealPause = 4 * time.Second
+
+	// A correct answer is worth basePoints plus up to speedPoints
+	// depending on how much of the countdown was left.
+	basePoints  = 500
+	speedPoints = 500
+
+	podiumSize = 3
+)
+
+type gameState int
+
+const (
+	gameIdle gameState = iota
+	gameQuestion
+	gameReveal
+)
+
+type startGameRequest struct {
+	QuizID       string `json:"quiz_id"`
+	QuestionTime int    `json:"question_time"` // seconds
+}
+
+type answerRequest struct {
+	QuestionIndex int `json:"question_index"`
+	Answer        any `json:"answer"`
+}
+
+// GameQuestion is the question payload pushed to players. It never carries the answer.
+type GameQuestion struct {
+	Index     int                `json:"index"`
+	Total     int                `json:"total"`
+	Type      model.QuestionType `json:"type"`
+	Text      string             `json:"text"`
+	Options   []string           `json:"options,omitempty"`
+	TimeLimit int                `json:"time_limit"`
+	Deadline  time.Time          `json:"deadline"`
+}
+
+// PlayerResult is the outcome of a single question for one player.
+type PlayerResult struct {
+	UserID  string `json:"user_id"`
+	Answer  any    `json:"answer"`
+	Correct bool   `json:"correct"`
+	Points  int    `json:"points"`
+}
+
+// Standing is a player's running total in the game.
+type Standing struct {
+	UserID  string `json:"user_id"`
+	Score   int    `json:"score"`
+	Correct int    `json:"correct"`
+	Rank    int    `json:"rank"`
+}
+
+type playerAnswer struct {
+	answer any
+	at     time.Time
+}
+
+// Game is the server-authoritative quiz loop for a single room.
+type Game struct {
+	room    *Room
+	quizzes QuizReader
+
+	mu           sync.Mutex
+	state        gameState
+	quiz         *model.Quiz
+	current      int
+	questionTime time.Duration
+	startedAt    time.Time
+	deadline     time.Time
+	answers      map[string]playerAnswer
+	scores       map[string]*Standing
+	allAnswered  chan struct{}
+}
+
+func NewGame(room *Room, quizzes QuizReader) *Game {
+	return &Game{
+		room:    room,
+		quizzes: quizzes,
+	}
+}
+
+// HandleMessage processes a game message sent by a client of the room.
+// It reports whether the message was consumed by the game.
+func (g *Game) HandleMessage(c *Client, msg Message) bool {
+	switch msg.Type {
+	case MsgStartGame:
+		g.start(c, msg)
+	case MsgAnswer:
+		g.answer(c, msg)
+	default:
+		return false
+	}
+	return true
+}
+
+func (g *Game) start(c *Client, msg Message) {
+	if c.UserID == "" || c.UserID != g.room.HostID {
+		g.sendError(c, "only the host can start the game")
+		return
+	}
+
+	var req startGameRequest
+	if err := decodeData(msg.Data, &req); err != nil {
+		g.sendError(c, "invalid START_GAME payload")
+		return
+	}
+	quizID, err := primitive.ObjectIDFromHex(req.QuizID)
+	if err != nil {
+		g.sendError(c, "invalid quiz id")
+		return
+	}
+
+	g.mu.Lock()
+	if g.state != gameIdle {
+		g.mu.Unlock()
+		g.sendError(c, "a game is already running in this room")
+		return
+	}
+	// Reserve the room while the quiz is loading so a second START_GAME is rejected.
+	g.state = gameReveal
+	g.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	quiz, err := g.quizzes.GetQuizByID(ctx, quizID)
+	if err == nil {
+		// Quizzes with rules draw one set of bank questions for the whole room
+		quiz, err = g.quizzes.AssembleQuiz(ctx, quiz, rand.Int64())
+	}
+	// Unpublished, unlisted and private quizzes can only be played by their owner
+	if err != nil || len(quiz.Questions) == 0 || ((!quiz.IsPublic() || !quiz.Listed()) && quiz.UserID.Hex() != c.UserID) {
+		cancel()
+		g.mu.Lock()
+		g.state = gameIdle
+		g.mu.Unlock()
+		g.sendError(c, "quiz not found or has no questions")
+		return
+	}
+	// The host's plan must include the quiz
+	err = g.quizzes.CheckQuizAccess(ctx, c.UserID, c.Role, quiz)
+	cancel()
+	if err != nil {
+		g.mu.Lock()
+		g.state = gameIdle
+		g.mu.Unlock()
+		g.sendError(c, err.Error())
+		return
+	}
+
+	questionTime := defaultQuestionTime
+	if req.QuestionTime > 0 {
+		questionTime = time.Duration(req.QuestionTime) * time.Second
+	}
+	questionTime = min(max(questionTime, minQuestionTime), maxQuestionTime)
+
+	g.mu.Lock()
+	g.quiz = quiz
+	g.questionTime = questionTime
+	g.scores = make(map[string]*Standing)
+	for _, userID := range g.room.players() {
+		g.scores[userID] = &Standing{UserID: userID}
+	}
+	g.mu.Unlock()
+
+	g.broadcast(MsgGameStarted, map[string]any{
+		"quiz_id":         quiz.ID.Hex(),
+		"title":           quiz.Title,
+		"total_questions": len(quiz.Questions),
+		"question_time":   int(questionTime.Seconds()),
+	})
+
+	go g.run()
+}
+
+// run drives the game from the first question to the podium.
+func (g *Game) run() {
+	total := len(g.quiz.Questions)
+	for i := 0; i < total; i++ {
+		done := g.ask(i)
+		g.countdown(i, done)
+		g.reveal(i)
+		if i < total-1 {
+			time.Sleep(revealPause)
+		}
+	}
+	g.finish()
+}
+
+func (g *Game) ask(index int) <-chan struct{} {
+	g.mu.Lock()
+	q := g.quiz.Questions[index]
+	g.state = gameQuestion
+	g.current = index
+	g.startedAt = time.Now()
+	g.deadline = g.startedAt.Add(g.questionTime)
+	g.answers = make(map[string]playerAnswer)
+	g.allAnswered = make(chan struct{})
+	payload := GameQuestion{
+		Index:     index,
+		Total:     len(g.quiz.Questions),
+		Type:      q.Kind(),
+		Text:      q.Text,
+		Options:   q.Options,
+		TimeLimit: int(g.questionTime.Seconds()),
+		Deadline:  g.deadline,
+	}
+	done := g.allAnswered
+	g.mu.Unlock()
+
+	g.broadcast(MsgQuestion, payload)
+	return done
+}
+
+// countdown ticks every second until the deadline or until every player has answered.
+func (g *Game) countdown(index int, done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	timer := time.NewTimer(g.questionTime)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+			return
+		case <-ticker.C:
+			remaining := int(time.Until(g.deadline).Round(time.Second).Seconds())
+			if remaining < 0 {
+				remaining = 0
+			}
+			g.broadcast(MsgCountdown, map[string]int{
+				"question_index": index,
+				"remaining":      remaining,
+			})
+		}
+	}
+}
+
+func (g *Game) answer(c *Client, msg Message) {
+	var req answerRequest
+	if err := decodeData(msg.Data, &req); err != nil {
+		g.sendError(c, "invalid ANSWER payload")
+		return
+	}
+	if c.UserID == "" {
+		g.sendError(c, "authentication required to play")
+		return
+	}
+
+	g.mu.Lock()
+	if g.state != gameQuestion || req.QuestionIndex != g.current {
+		g.mu.Unlock()
+		g.sendError(c, "question is not open for answers")
+		return
+	}
+	now := time.Now()
+	if now.After(g.deadline) {
+		g.mu.Unlock()
+		g.sendError(c, "time is up")
+		return
+	}
+	if _, ok := g.answers[c.UserID]; ok {
+		g.mu.Unlock()
+		g.sendError(c, "answer already submitted")
+		return
+	}
+	// Players who joined after the start still get to play.
+	if _, ok := g.scores[c.UserID]; !ok {
+		g.scores[c.UserID] = &Standing{UserID: c.UserID}
+	}
+	g.answers[c.UserID] = playerAnswer{answer: req.Answer, at: now}
+	if len(g.answers) >= len(g.room.players()) {
+		select {
+		case <-g.allAnswered:
+		default:
+			close(g.allAnswered)
+		}
+	}
+	g.mu.Unlock()
+
+	g.send(c, MsgAnswerReceived, map[string]int{"question_index": req.QuestionIndex})
+}
+
+func (g *Game) reveal(index int) {
+	g.mu.Lock()
+	g.state = gameReveal
+	q := g.quiz.Questions[index]
+
+	results := make([]PlayerResult, 0, len(g.scores))
+	for userID, standing := range g.scores {
+		result := PlayerResult{UserID: userID}
+		if pa, ok := g.answers[userID]; ok {
+			result.Answer = pa.answer
+			if q.Grade(pa.answer) {
+				result.Correct = true
+				result.Points = g.points(pa.at)
+				standing.Score += result.Points
+				standing.Correct++
+			}
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Points > results[j].Points })
+	standings := g.standings()
+	g.mu.Unlock()
+
+	g.broadcast(MsgQuestionResult, map[string]any{
+		"question_index": index,
+		"correct_answer": q.CorrectAnswer(),
+		"results":        results,
+		"standings":      standings,
+	})
+}
+
+func (g *Game) finish() {
+	g.mu.Lock()
+	standings := g.standings()
+	g.state = gameIdle
+	g.quiz = nil
+	g.mu.Unlock()
+
+	podium := standings
+	if len(podium) > podiumSize {
+		podium = podium[:podiumSize]
+	}
+	g.broadcast(MsgGameOver, map[string]any{
+		"podium":    podium,
+		"standings": standings,
+	})
+}
+
+// points rewards a correct answer, with a bonus for answering quickly.
+// Must be called with g.mu held.
+func (g *Game) points(answeredAt time.Time) int {
+	remaining := g.deadline.Sub(answeredAt)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return basePoints + int(float64(speedPoints)*remaining.Seconds()/g.questionTime.Seconds())
+}
+
+// standings returns the players ranked by score. Must be called with g.mu held.
+func (g *Game) standings() []Standing {
+	standings := make([]Standing, 0, len(g.scores))
+	for _, s := range g.scores {
+		standings = append(standings, *s)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Score != standings[j].Score {
+			return standings[i].Score > standings[j].Score
+		}
+		return standings[i].Correct > standings[j].Correct
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
+
+func (g *Game) broadcast(msgType string, data any) {
+	g.room.send(Message{Type: msgType, Data: data, RoomID: g.room.ID})
+}
+
+func (g *Game) send(c *Client, msgType string, data any) {
+	payload, err := json.Marshal(Message{Type: msgType, Data: data, RoomID: g.room.ID})
+	if err != nil {
+		log.Printf("Error marshalling game message: %v", err)
+		return
+	}
+	g.room.mu.RLock()
+	defer g.room.mu.RUnlock()
+	if _, ok := g.room.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.Send <- payload:
+	default:
+	}
+}
+
+func (g *Game) sendError(c *Client, message string) {
+	g.send(c, MsgGameError, map[string]string{"error": message})
+}
+
+// decodeData converts the loosely typed Message.Data into a typed request.
+func decodeData(data any, v any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sachinggsingh/quiz/internal/model"
 	"github.com/sachinggsingh/quiz/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type LeaderboardService interface {
@@ -21,17 +22,27 @@ type SubscriptionReader interface {
 	GetSubscription(ctx context.Context, userID string) (*model.Subscription, error)
 }
 
+// QuizReader defines the minimal quiz API needed to run a live game in a room.
+// It is implemented by the quiz service in the service package.
+type QuizReader interface {
+	GetQuizByID(ctx context.Context, id primitive.ObjectID) (*model.Quiz, error)
+	AssembleQuiz(ctx context.Context, quiz *model.Quiz, seed int64) (*model.Quiz, error)
+	CheckQuizAccess(ctx context.Context, userID string, role string, quiz *model.Quiz) error
+}
+
 type Handler struct {
 	hub                 *Hub
 	leaderboardService  LeaderboardService
 	subscriptionService SubscriptionReader
+	quizReader          QuizReader
 }
 
-func NewHandler(hub *Hub, leaderboardService LeaderboardService, subscriptionService SubscriptionReader) *Handler {
+func NewHandler(hub *Hub, leaderboardService LeaderboardService, subscriptionService SubscriptionReader, quizReader QuizReader) *Handler {
 	return &Handler{
 		hub:                 hub,
 		leaderboardService:  leaderboardService,
 		subscriptionService: subscriptionService,
+		quizReader:          quizReader,
 	}
 }
 
@@ -83,7 +94,7 @@ func (h *Handler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if sub.Status != model.StatusActive || sub.StripeSubscriptionID == "" {
+	if !sub.IsActive() {
 		http.Error(w, "active subscription required", http.StatusForbidden)
 		return
 	}
@@ -95,9 +106,9 @@ func (h *Handler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create the room
-	// Create the room with the current user as host
-	h.hub.CreateRoom(roomID, userID)
+	// Create the room with the current user as host and attach its game loop
+	room := h.hub.CreateRoom(roomID, userID)
+	room.Game = NewGame(room, h.quizReader)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -137,6 +148,7 @@ func (h *Handler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 		Send:   make(chan []byte, 256),
 		RoomID: roomID,
 		UserID: userID,
+		Role:   utils.GetRole(r.Context()),
 	}
 
 	h.hub.register <- client
@@ -21,6 +21,7 @@ type Room struct {
 	broadcast  chan Message
 	register   chan *Client
 	unregister chan *Client
+	Game       *Game
 }
 
 func NewRoom(id string, hostId string) *Room {
@@ -34,6 +35,40 @@ func NewRoom(id string, hostId string) *Room {
 	}
 }
 
+// players returns the distinct authenticated users currently in the room.
+func (r *Room) players() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := make(map[string]bool, len(r.clients))
+	players := make([]string, 0, len(r.clients))
+	for client := range r.clients {
+		if client.UserID == "" || seen[client.UserID] {
+			continue
+		}
+		seen[client.UserID] = true
+		players = append(players, client.UserID)
+	}
+	return players
+}
+
+// send delivers the message to every client of the room in order, without
+// going through the hub worker pool.
+func (r *Room) send(message Message) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshalling room message: %v", err)
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for client := range r.clients {
+		select {
+		case client.Send <- data:
+		default:
+		}
+	}
+}
+
 type Hub struct {
 	// Registered clients.
 	clients map[*Client]bool
@@ -172,6 +207,13 @@ func (h *Hub) Run() {
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
+				// Drop the client from its room before closing Send so room
+				// broadcasts never write to a closed channel.
+				if room := h.getRoom(client.RoomID); room != nil {
+					room.mu.Lock()
+					delete(room.clients, client)
+					room.mu.Unlock()
+				}
 				close(client.Send)
 			}
 			h.mu.Unlock()